@@ -0,0 +1,161 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"log"
+	"time"
+)
+
+//Subscribes to whatever the achievement needs to watch during a mission. Calls unlock when the condition is met.
+type AchievementTrigger func(game *Game, scope *SignalScope, unlock func())
+
+type Achievement struct {
+	id          string //Key used in the save file. Don't change it once released!
+	name        string
+	description string
+	trigger     AchievementTrigger
+}
+
+//Makes a trigger that unlocks the achievement when an event of type E is emitted and the condition holds
+func When[E Event](cond func(game *Game, ev E) bool) AchievementTrigger {
+	return func(game *Game, scope *SignalScope, unlock func()) {
+		scope.Track(Listen_Signal(func(ev E) {
+			if cond(game, ev) {
+				unlock()
+			}
+		}))
+	}
+}
+
+var achievements = []*Achievement{
+	{
+		id:          "par_all",
+		name:        "PUNCTUAL",
+		description: "BEAT EVERY PAR TIME IN ONE RUN",
+		trigger: When(func(game *Game, ev CatDied) bool {
+			if game.missionNumber != len(missions)-1 || game.elapsedTime >= float64(game.mission.parTime) {
+				return false
+			}
+			for i := 0; i < game.missionNumber; i++ {
+				if !missions[i].goodEndFlag {
+					return false
+				}
+			}
+			return true
+		}),
+	},
+	{
+		id:          "no_descend",
+		name:        "STEADFAST",
+		description: "CLEAR A MISSION WITHOUT DESCENDING",
+		trigger: When(func(game *Game, ev CatDied) bool {
+			return game.missionNumber > 0 && game.stats.Descents == 0
+		}),
+	},
+	{
+		id:          "one_ascension",
+		name:        "FIRST TRY",
+		description: "KILL THE CAT IN ONE ASCENSION",
+		trigger: When(func(game *Game, ev CatDied) bool {
+			return game.stats.Ascensions == 1
+		}),
+	},
+	{
+		id:          "rune_chain",
+		name:        "CHAIN REACTION",
+		description: "SET OFF 5 RUNES IN ONE CHAIN",
+		trigger: When(func(game *Game, ev RuneExploded) bool {
+			return ev.Chain >= 5
+		}),
+	},
+	{
+		id:          "accuracy",
+		name:        "SHARPSHOOTER",
+		description: "FINISH A MISSION WITH 75% ACCURACY",
+		trigger: When(func(game *Game, ev CatDied) bool {
+			return game.stats.ShotsFired >= 20 && game.stats.Accuracy() >= 0.75
+		}),
+	},
+	{
+		id:          "unhurt",
+		name:        "UNTOUCHABLE",
+		description: "FINISH A MISSION UNHURT",
+		trigger: When(func(game *Game, ev CatDied) bool {
+			return game.missionNumber > 0 && game.stats.TimesHurt == 0
+		}),
+	},
+	{
+		id:          "kills_100",
+		name:        "EXTERMINATOR",
+		description: "KILL 100 MONSTERS IN ONE RUN",
+		trigger: When(func(game *Game, ev EnemyKilled) bool {
+			return __runStats.TotalKills() >= 100
+		}),
+	},
+}
+
+const ACHIEVEMENTS_FILE = "achievements.json"
+
+//Contents of the achievements save file
+type AchievementSave struct {
+	Unlocked map[string]time.Time `json:"unlocked"` //Time of unlocking by achievement id
+}
+
+var __achievementSave *AchievementSave
+
+//Returns the saved achievement progress, loading it if needed
+func achievementSave() *AchievementSave {
+	if __achievementSave == nil {
+		__achievementSave = &AchievementSave{Unlocked: make(map[string]time.Time)}
+		if err := LoadSaveFile(ACHIEVEMENTS_FILE, __achievementSave); err != nil {
+			log.Println("Cannot load achievements: ", err)
+		}
+		if __achievementSave.Unlocked == nil {
+			__achievementSave.Unlocked = make(map[string]time.Time)
+		}
+	}
+	return __achievementSave
+}
+
+func (ach *Achievement) Unlocked() bool {
+	_, ok := achievementSave().Unlocked[ach.id]
+	return ok
+}
+
+//Marks the achievement as unlocked, saves it, and lets everyone know. Does nothing if it was already unlocked, or if the bot is playing.
+func (ach *Achievement) Unlock() {
+	if __botPlaying || ach.Unlocked() {
+		return
+	}
+	save := achievementSave()
+	save.Unlocked[ach.id] = time.Now()
+	if err := WriteSaveFile(ACHIEVEMENTS_FILE, save); err != nil {
+		log.Println("Cannot save achievements: ", err)
+	}
+	Emit_Signal(AchievementUnlocked{Achievement: ach})
+}
+
+//Starts watching for the achievements that haven't been unlocked yet. The subscriptions are added to the scope.
+func WatchAchievements(game *Game, scope *SignalScope) {
+	for _, ach := range achievements {
+		if !ach.Unlocked() {
+			ach.trigger(game, scope, ach.Unlock)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"io"
+	"log"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/assets"
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+//Timings and projectile settings for an archetype's attack. Not every behavior uses every field.
+type AttackDef struct {
+	Interval  float64 `json:"interval"`  //Time in seconds between attacks
+	Duration  float64 `json:"duration"`  //How long the attack itself lasts
+	Windup    float64 `json:"windup"`    //Time spent showing the attack sprite
+	ShotSpeed float64 `json:"shotSpeed"` //Speed of projectiles
+	Bounces   int     `json:"bounces"`   //Number of wall bounces for projectiles
+	Shots     int     `json:"shots"`     //Number of projectiles fired per volley
+	Spin      float64 `json:"spin"`      //Angle in radians by which the volley rotates after each attack
+}
+
+//How far and how wide a monster can see. Zero values mean the defaults.
+type VisionDef struct {
+	Range float64 `json:"range"` //Distance in pixels
+	Cone  float64 `json:"cone"`  //Angle in degrees of the field of view, centered on the direction the monster faces
+}
+
+//Describes an enemy (or other spawnable thing) as it is laid out in assets/archetypes.json
+type archetypeData struct {
+	Name         string             `json:"name"`
+	Behavior     string             `json:"behavior"`
+	Speed        float64            `json:"speed"`
+	Acceleration float64            `json:"acceleration"`
+	Friction     float64            `json:"friction"`
+	Health       int                `json:"health"`
+	Points       int                `json:"points"` //Score for killing it, before the combo multiplier
+	Radius       float64            `json:"radius"`
+	Sprites      map[string][][]int `json:"sprites"` //Frames are given as [left, top, right, bottom] with an optional 5th element for orientation
+	AnimSpeed    float64            `json:"animSpeed"`
+	DieSpeed     float64            `json:"dieSpeed"`
+	Attack       AttackDef          `json:"attack"`
+	Vision       VisionDef          `json:"vision"`
+	Steering     SteeringDef        `json:"steering"`
+	Drops        map[string]int     `json:"drops"`
+	Params       map[string]float64 `json:"params"`
+}
+
+type Archetype struct {
+	name         string
+	behavior     string
+	maxSpeed     float64
+	acceleration float64
+	friction     float64
+	health       int
+	points       int
+	radius       float64
+	sprites      map[string][]*Sprite
+	animSpeed    float64 //Speed of the looping "normal" animation
+	dieSpeed     float64 //Speed of the death animation
+	attack       AttackDef
+	vision       VisionDef
+	steering     SteeringDef
+	drops        map[string]int     //Items dropped on death, by item name
+	params       map[string]float64 //Extra settings specific to the behavior
+}
+
+//Creates an object for the archetype at the given position. Returns nil if the spawn was rejected.
+type ArchetypeSpawner func(game *Game, arch *Archetype, x, y float64) *Object
+
+var archetypes map[string]*Archetype
+var archetypeSpawners map[string]ArchetypeSpawner
+
+func init() {
+	archetypeSpawners = map[string]ArchetypeSpawner{
+		"knight": AddKnight,
+		"blargh": AddBlargh,
+		"gopnik": AddGopnik,
+		"worm":   AddWorm,
+		"barrel": AddBarrel,
+	}
+	LoadArchetypes(assets.ReadCompressedString(assets.JSON_ARCHETYPES))
+}
+
+//Parses archetype definitions and adds them to the registry. Definitions with existing names replace the old ones.
+func LoadArchetypes(input io.Reader) {
+	var data []archetypeData
+	if err := json.NewDecoder(input).Decode(&data); err != nil {
+		log.Fatalln("Cannot parse archetype definitions: ", err)
+	}
+	if archetypes == nil {
+		archetypes = make(map[string]*Archetype)
+	}
+	for _, d := range data {
+		if _, ok := archetypeSpawners[d.Behavior]; !ok {
+			log.Fatalf("Archetype %s has unknown behavior %s.\n", d.Name, d.Behavior)
+		}
+		arch := &Archetype{
+			name:         d.Name,
+			behavior:     d.Behavior,
+			maxSpeed:     d.Speed,
+			acceleration: d.Acceleration,
+			friction:     d.Friction,
+			health:       d.Health,
+			points:       d.Points,
+			radius:       d.Radius,
+			sprites:      make(map[string][]*Sprite),
+			animSpeed:    d.AnimSpeed,
+			dieSpeed:     d.DieSpeed,
+			attack:       d.Attack,
+			vision:       d.Vision,
+			steering:     d.Steering,
+			drops:        d.Drops,
+			params:       d.Params,
+		}
+		for key, frames := range d.Sprites {
+			if arch.sprites[key] = ParseSpriteFrames(frames); arch.sprites[key] == nil {
+				log.Fatalf("Archetype %s has a malformed %s frame.\n", d.Name, key)
+			}
+		}
+		archetypes[d.Name] = arch
+	}
+}
+
+//Makes sprites out of frames given as [left, top, right, bottom] with an optional 5th element for orientation.
+//Returns nil if any of the frames is malformed.
+func ParseSpriteFrames(frames [][]int) []*Sprite {
+	sprites := make([]*Sprite, len(frames))
+	for i, f := range frames {
+		if len(f) < 4 {
+			return nil
+		}
+		rect := image.Rect(f[0], f[1], f[2], f[3])
+		orient := 0
+		if len(f) > 4 {
+			orient = f[4]
+		}
+		//Sprites are centered on the object's position
+		ofs := vmath.NewVec(-float64(rect.Dx())/2.0, -float64(rect.Dy())/2.0)
+		sprites[i] = NewSprite(rect, ofs, false, false, orient)
+	}
+	return sprites
+}
+
+func GetArchetype(name string) *Archetype {
+	arch, ok := archetypes[name]
+	if !ok {
+		log.Println("No archetype named ", name)
+		return nil
+	}
+	return arch
+}
+
+//Adds an instance of the named archetype to the game
+func SpawnArchetype(game *Game, name string, x, y float64) *Object {
+	arch := GetArchetype(name)
+	if arch == nil {
+		return nil
+	}
+	return archetypeSpawners[arch.behavior](game, arch, x, y)
+}
+
+//Returns the first frame of the named sprite set, or nil if the archetype doesn't have it
+func (arch *Archetype) Sprite(key string) *Sprite {
+	if frames := arch.sprites[key]; len(frames) > 0 {
+		return frames[0]
+	}
+	return nil
+}
+
+func (arch *Archetype) Param(key string, def float64) float64 {
+	if val, ok := arch.params[key]; ok {
+		return val
+	}
+	return def
+}
+
+//Spawns the items the archetype leaves behind when it dies
+func (arch *Archetype) DropLoot(game *Game, x, y float64) {
+	if n := arch.drops["love"]; n > 0 {
+		AddLove(game, n, x, y)
+	}
+}
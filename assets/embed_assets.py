@@ -10,7 +10,7 @@ import base64
 data = {}
 for root, dirs, files in os.walk(".", topdown=True):
   for file_name in files:
-    if file_name.endswith((".png", ".wav", ".ogg")):
+    if file_name.endswith((".png", ".wav", ".ogg", ".json")):
       with open(file_name, "rb") as fin:
         root_name, extension = os.path.splitext(file_name)
         key_name = extension.upper().strip(".") + "_" + root_name.upper()
@@ -18,46 +18,32 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
-	"image"
-
 	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
 )
 
 type Barrel struct {
+	arch   *Archetype
 	health int
 }
 
-var sprBarrel *Sprite
-var sprBarrelDamaged *Sprite
-
-func init() {
-	sprBarrel = NewSprite(image.Rect(16, 128, 32, 144), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprBarrelDamaged = NewSprite(image.Rect(0, 144, 16, 160), vmath.NewVec(-8.0, -8.0), false, false, 0)
-}
-
-var barrelCtr *ObjCtr
-
-func init() {
-	barrelCtr = NewObjCtr()
-}
-
-func AddBarrel(game *Game, x, y float64) *Object {
-	barrelCtr.Inc()
+func AddBarrel(game *Game, arch *Archetype, x, y float64) *Object {
 	return game.AddObject(&Object{
-		pos: vmath.NewVec(x, y), radius: 6.0, colType: CT_BARREL,
-		sprites:      []*Sprite{sprBarrel},
-		drawPriority: -1,
+		pos: vmath.NewVec(x, y), radius: arch.radius, colType: CT_BARREL,
+		archetype: arch.name,
+		sprites:   []*Sprite{arch.Sprite("normal")},
+		layer:     RL_GROUND,
 		components: []Component{&Barrel{
-			health: 40,
+			arch:   arch,
+			health: arch.health,
 		}},
 	})
 }
 
 func (brl *Barrel) Update(game *Game, obj *Object) {
-	if brl.health < 20 {
-		obj.sprites[0] = sprBarrelDamaged
+	if brl.health < brl.arch.health/2 {
+		obj.sprites[0] = brl.arch.Sprite("hurt")
 	} else {
-		obj.sprites[0] = sprBarrel
+		obj.sprites[0] = brl.arch.Sprite("normal")
 	}
 }
 
@@ -72,7 +58,7 @@ func (brl *Barrel) OnCollision(game *Game, obj, other *Object) {
 	}
 	if brl.health <= 0 && !obj.removeMe {
 		obj.removeMe = true
-		barrelCtr.Dec()
+		brl.arch.DropLoot(game, obj.pos.X, obj.pos.Y)
 		AddExplosion(game, obj.pos.X, obj.pos.Y)
 	}
 }
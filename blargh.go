@@ -18,7 +18,6 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
-	"image"
 	"math/rand"
 
 	"github.com/thetophatdemon/feta-feles-rebirth/audio"
@@ -27,84 +26,122 @@ import (
 
 type Blargh struct {
 	Mob
-	shootTimer float64
+	arch *Archetype
 }
 
-var sprBlarghNormal *Sprite
-var sprBlarghShoot *Sprite
-var sprBlarghHurt *Sprite
-var sprBlarghDie []*Sprite
-
-func init() {
-	sprBlarghNormal = NewSprite(image.Rect(0, 48, 16, 64), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprBlarghShoot = NewSprite(image.Rect(16, 48, 32, 64), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprBlarghHurt = NewSprite(image.Rect(32, 48, 48, 64), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprBlarghDie = NewSprites(vmath.NewVec(-8.0, -8.0), image.Rect(32, 48, 48, 64), image.Rect(48, 48, 64, 64))
-}
-
-var blarghCtr *ObjCtr
-
-func init() {
-	blarghCtr = NewObjCtr()
-}
-
-func AddBlargh(game *Game, x, y float64) *Object {
+func AddBlargh(game *Game, arch *Archetype, x, y float64) *Object {
 	blargh := &Blargh{
 		Mob: Mob{
-			Actor:             NewActor(50.0, 100_000.0, 50_000.0),
-			health:            5,
+			Actor:             NewActor(arch.maxSpeed, arch.acceleration, arch.friction),
+			vision:            arch.vision,
+			steering:          arch.steering,
+			health:            arch.health,
 			currAnim:          nil,
 			lastSeenPlayerPos: vmath.ZeroVec(),
 			vecToPlayer:       vmath.ZeroVec(),
 		},
-		shootTimer: rand.Float64()/2.0 + 0.5,
+		arch: arch,
 	}
-	blarghCtr.Inc()
-	return game.AddObject(&Object{
-		pos: vmath.NewVec(x, y), radius: 7.0, colType: CT_ENEMY,
-		sprites:    []*Sprite{sprBlarghNormal},
+	obj := &Object{
+		pos: vmath.NewVec(x, y), radius: arch.radius, colType: CT_ENEMY,
+		archetype:  arch.name,
+		sprites:    []*Sprite{arch.Sprite("normal")},
 		components: []Component{blargh},
-	})
+	}
+	blargh.ai = blargh.NewAI(game, obj)
+	return game.AddObject(obj)
 }
 
-const (
-	BLARGH_SHOOT_INTERVAL  float64 = 2.0
-	BLARGH_SHOOT_THRESHOLD float64 = 0.5 //The actual shot is made _ seconds before the timer reaches 0, so the animation can look better
-)
+//Blarghs stop to think, waddle toward the player, and spit a bouncing shot at them. They wait when the player is out of sight unless they hear something.
+func (bl *Blargh) NewAI(game *Game, obj *Object) *StateMachine {
+	atk := &bl.arch.attack
+	sm := NewStateMachine(AI_IDLE)
+	sm.Define(AI_IDLE, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			bl.Move(0.0, 0.0)
+		},
+		Update: func(game *Game, obj *Object) {
+			if bl.hunting {
+				sm.Change(game, obj, AI_CHASE)
+				sm.Advance(rand.Float64() * (atk.Interval - atk.Duration - atk.Windup))
+			} else if bl.heardPos != nil {
+				sm.Change(game, obj, AI_SEARCH)
+			}
+		},
+	})
+	//Waits until the player shows up again, checking out any noises in the meantime
+	sm.Define(AI_SEARCH, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			bl.Move(0.0, 0.0)
+		},
+		Update: func(game *Game, obj *Object) {
+			if bl.seesPlayer {
+				sm.Change(game, obj, AI_ALERT)
+				return
+			}
+			dir := bl.Investigate(game, obj)
+			bl.Move(dir.X, dir.Y)
+		},
+	})
+	//Standing still before moving
+	sm.Define(AI_ALERT, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			bl.Move(0.0, 0.0)
+		},
+		Timeout: atk.Duration,
+		Next:    AI_CHASE,
+	})
+	//Blarghs hang back at firing distance instead of crowding the player
+	sm.Define(AI_CHASE, StateDef{
+		Update: func(game *Game, obj *Object) {
+			dir := bl.Steer(game, obj, bl.ChaseDirection(game, obj))
+			bl.Move(dir.X, dir.Y)
+		},
+		Timeout: atk.Interval - atk.Duration - atk.Windup,
+		Next:    AI_ATTACK,
+	})
+	//The shot is fired as the attack sprite shows up, and the blargh keeps moving until the end of the windup
+	sm.Define(AI_ATTACK, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			AddBouncyShot(game, obj.pos.Clone(), bl.vecToPlayer.Clone(), atk.ShotSpeed, true, atk.Bounces)
+		},
+		Update: func(game *Game, obj *Object) {
+			dir := bl.Steer(game, obj, bl.ChaseDirection(game, obj))
+			bl.Move(dir.X, dir.Y)
+		},
+		Timeout: atk.Windup,
+		Next:    AI_SEARCH,
+	})
+	sm.Define(AI_DYING, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			bl.dead = true
+			bl.Move(0.0, 0.0)
+			audio.PlaySound("enemy_die")
+			bl.currAnim = &Anim{
+				frames: bl.arch.sprites["die"],
+				speed:  bl.arch.dieSpeed,
+				callback: func(anm *Anim) {
+					if anm.finished {
+						obj.removeMe = true
+						bl.arch.DropLoot(game, obj.pos.X, obj.pos.Y)
+					}
+				},
+			}
+		},
+	})
+	return sm
+}
 
 func (bl *Blargh) Update(game *Game, obj *Object) {
 	if bl.hurtTimer > 0.0 {
-		obj.sprites[0] = sprBlarghHurt
-	} else if bl.shootTimer < BLARGH_SHOOT_THRESHOLD {
-		obj.sprites[0] = sprBlarghShoot
+		obj.sprites[0] = bl.arch.Sprite("hurt")
+	} else if bl.ai.State() == AI_ATTACK {
+		obj.sprites[0] = bl.arch.Sprite("attack")
 	} else {
-		obj.sprites[0] = sprBlarghNormal
+		obj.sprites[0] = bl.arch.Sprite("normal")
 	}
 
 	bl.Mob.Update(game, obj)
-
-	if bl.hunting {
-		if bl.shootTimer > BLARGH_SHOOT_THRESHOLD && bl.shootTimer-game.deltaTime < BLARGH_SHOOT_THRESHOLD {
-			AddBouncyShot(game, obj.pos.Clone(), bl.vecToPlayer.Clone(), 80.0, true, 2)
-		}
-		//Move for 0.5 seconds after timer starts
-		if bl.shootTimer < BLARGH_SHOOT_INTERVAL-0.5 {
-			bl.Move(bl.vecToPlayer.X, bl.vecToPlayer.Y)
-		} else {
-			bl.Move(0.0, 0.0)
-		}
-		if bl.shootTimer < BLARGH_SHOOT_INTERVAL {
-			bl.shootTimer -= game.deltaTime
-			if bl.shootTimer < 0.0 {
-				bl.shootTimer = BLARGH_SHOOT_INTERVAL
-			}
-		} else {
-			if bl.seesPlayer { //Restart ticking once player is spotted again
-				bl.shootTimer -= game.deltaTime
-			}
-		}
-	}
-
 	bl.Actor.Update(game, obj)
 }
 
@@ -113,18 +150,6 @@ func (bl *Blargh) OnCollision(game *Game, obj, other *Object) {
 
 	//Death
 	if bl.health <= 0 && !bl.dead {
-		bl.dead = true
-		audio.PlaySound("enemy_die")
-		bl.currAnim = &Anim{
-			frames: sprBlarghDie,
-			speed:  0.15,
-			callback: func(anm *Anim) {
-				if anm.finished {
-					obj.removeMe = true
-					blarghCtr.Dec()
-					AddLove(game, 4, obj.pos.X, obj.pos.Y)
-				}
-			},
-		}
+		bl.ai.Change(game, obj, AI_DYING)
 	}
 }
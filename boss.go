@@ -0,0 +1,243 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"image"
+	"math"
+	"math/rand"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/audio"
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+const (
+	DEMON_HEALTH      = 600
+	DEMON_RADIUS      = 36.0
+	DEMON_PHASES      = 3     //The demon gets more dangerous each time it loses this fraction of its health
+	DEMON_ROAR_TIME   = 2.0   //Time spent roaring between phases, during which it can't be hurt
+	DEMON_SHOCKWAVE   = 112.0 //Radius of the walls blown away between phases
+	DEMON_CHARGE_TIME = 1.0
+	DEMON_DEATH_TIME  = 3.0
+)
+
+//Attack timings and movement speeds for each phase
+var demonDrift = [DEMON_PHASES]float64{30.0, 45.0, 60.0}
+var demonVolleyTime = [DEMON_PHASES]float64{2.0, 1.5, 0.1}
+var demonChargeTime = [DEMON_PHASES]float64{0.0, 0.0, 4.0} //Seconds between charges. Zero means no charging.
+
+var sprDemon *Sprite
+
+func init() {
+	sprDemon = NewSprite(image.Rect(0, 160, 128, 256), vmath.NewVec(-64.0, -48.0), false, false, 0)
+}
+
+//The final boss. It floats through walls, crushing them as it goes.
+type Demon struct {
+	Mob
+	phase       int
+	volleyTimer float64
+	volleys     int     //Number of volleys fired in the current phase
+	shootAngle  float64 //Rotation of the ring and spiral attacks
+	deathTimer  float64
+}
+
+func AddDemon(game *Game, x, y float64) (*Demon, *Object) {
+	demon := &Demon{
+		Mob: Mob{
+			Actor:             NewActor(demonDrift[0], 50_000.0, 25_000.0),
+			health:            DEMON_HEALTH,
+			lastSeenPlayerPos: vmath.ZeroVec(),
+			vecToPlayer:       vmath.ZeroVec(),
+			hunting:           true,
+			perceiving:        true, //It always knows where the player is
+		},
+		shootAngle: rand.Float64() * math.Pi * 2.0,
+	}
+	obj := &Object{
+		pos: vmath.NewVec(x, y), radius: DEMON_RADIUS, colType: CT_ENEMY,
+		archetype:  "demon",
+		sprites:    []*Sprite{sprDemon},
+		components: []Component{demon},
+	}
+	demon.ai = demon.NewAI(game, obj)
+	game.AddObject(obj)
+	AddStarBurst(game, x, y)
+	audio.PlaySound("roar")
+	return demon, obj
+}
+
+//Returns how much health the demon has left, from 0 to 1
+func (demon *Demon) HealthPercent() float64 {
+	return math.Max(0.0, float64(demon.health)/float64(DEMON_HEALTH))
+}
+
+//The demon drifts after the player, firing volleys of shots that change with each phase.
+//Between phases it stops to roar and blows away the walls around it. In the last phase it also charges at the player.
+func (demon *Demon) NewAI(game *Game, obj *Object) *StateMachine {
+	sm := NewStateMachine(AI_CHASE)
+	sm.Define(AI_CHASE, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			demon.maxSpeed = demonDrift[demon.phase]
+			if demonChargeTime[demon.phase] > 0.0 {
+				sm.SetTimeout(demonChargeTime[demon.phase])
+			}
+		},
+		Update: func(game *Game, obj *Object) {
+			if demon.phase < DEMON_PHASES-1 && demon.HealthPercent() <= 1.0-float64(demon.phase+1)/float64(DEMON_PHASES) {
+				sm.Change(game, obj, AI_ALERT)
+				return
+			}
+			demon.Move(demon.vecToPlayer.X, demon.vecToPlayer.Y)
+			demon.volleyTimer += game.deltaTime
+			if demon.volleyTimer > demonVolleyTime[demon.phase] {
+				demon.volleyTimer = 0.0
+				demon.Volley(game, obj)
+			}
+		},
+		Next: AI_ATTACK,
+	})
+	//Charging at the player
+	sm.Define(AI_ATTACK, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			demon.maxSpeed = demonDrift[demon.phase] * 3.0
+			demon.Move(demon.vecToPlayer.X, demon.vecToPlayer.Y)
+			audio.PlaySoundAttenuated("roar", 256.0, obj.pos, game.camMin, game.camMax)
+		},
+		Timeout: DEMON_CHARGE_TIME,
+		Next:    AI_CHASE,
+	})
+	//Roaring between phases
+	sm.Define(AI_ALERT, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			demon.phase++
+			demon.volleys = 0
+			demon.volleyTimer = 0.0
+			demon.Move(0.0, 0.0)
+			audio.PlaySound("roar")
+			demon.Shockwave(game, obj)
+		},
+		Timeout: DEMON_ROAR_TIME,
+		Next:    AI_CHASE,
+	})
+	sm.Define(AI_DYING, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			demon.dead = true
+			demon.Move(0.0, 0.0)
+			audio.PlaySound("roar")
+		},
+		Update: func(game *Game, obj *Object) {
+			demon.deathTimer += game.deltaTime
+			//Bursts of stars all over its body. Explosions would hurt the player.
+			if int(demon.deathTimer*8.0) != int((demon.deathTimer-game.deltaTime)*8.0) {
+				ofs := vmath.RandomDirection().Scale(rand.Float64() * obj.radius)
+				AddStarBurst(game, obj.pos.X+ofs.X, obj.pos.Y+ofs.Y)
+				audio.PlaySoundAttenuated("enemy_die", 256.0, obj.pos, game.camMin, game.camMax)
+			}
+			obj.hidden = int(demon.deathTimer*16.0)%2 == 0
+			if demon.deathTimer > DEMON_DEATH_TIME {
+				obj.removeMe = true
+				AddLove(game, 20, obj.pos.X, obj.pos.Y)
+				Emit_Signal(BossDefeated{Boss: obj, Pos: obj.pos.Clone()})
+			}
+		},
+	})
+	return sm
+}
+
+//Fires the current phase's attack
+func (demon *Demon) Volley(game *Game, obj *Object) {
+	demon.volleys++
+	switch demon.phase {
+	case 0:
+		//Rings of shots, like a big gopnik
+		for i := 0; i < 12; i++ {
+			a := demon.shootAngle + float64(i)*math.Pi*2.0/12.0
+			AddShot(game, obj.pos.Clone(), vmath.VecFromAngle(a, 1.0), 50.0, true)
+		}
+		demon.shootAngle += math.Pi / 12.0
+	case 1:
+		//Spreads of bouncy shots aimed at the player, with a ring every third volley
+		if demon.volleys%3 == 0 {
+			for i := 0; i < 16; i++ {
+				a := demon.shootAngle + float64(i)*math.Pi*2.0/16.0
+				AddShot(game, obj.pos.Clone(), vmath.VecFromAngle(a, 1.0), 60.0, true)
+			}
+		} else {
+			aim := math.Atan2(demon.vecToPlayer.Y, demon.vecToPlayer.X)
+			for i := -2; i <= 2; i++ {
+				AddBouncyShot(game, obj.pos.Clone(), vmath.VecFromAngle(aim+float64(i)*0.2, 1.0), 80.0, true, 1)
+			}
+		}
+	default:
+		//Double spiral
+		AddShot(game, obj.pos.Clone(), vmath.VecFromAngle(demon.shootAngle, 1.0), 70.0, true)
+		AddShot(game, obj.pos.Clone(), vmath.VecFromAngle(demon.shootAngle+math.Pi, 1.0), 70.0, true)
+		demon.shootAngle += 0.3
+	}
+	MakeNoise(obj.pos, NOISE_SHOT, obj)
+}
+
+//Blows away the walls around the demon, opening up the arena for the next phase
+func (demon *Demon) Shockwave(game *Game, obj *Object) {
+	for _, t := range game.level.GetTilesWithinRadius(obj.pos, DEMON_SHOCKWAVE) {
+		if t.IsSolid() {
+			AddPoof(game, t.centerX, t.centerY)
+		}
+		game.level.DestroyTile(t)
+	}
+	AddStarBurst(game, obj.pos.X, obj.pos.Y)
+	MakeNoise(obj.pos, NOISE_EXPLOSION, obj)
+}
+
+func (demon *Demon) Update(game *Game, obj *Object) {
+	if demon.health <= 0 && !demon.dead {
+		demon.ai.Change(game, obj, AI_DYING)
+	}
+
+	demon.Mob.Update(game, obj)
+	if demon.dead {
+		return
+	}
+	//Crush whatever walls it floats into, so that it never gets stuck
+	for _, t := range game.level.GetTilesWithinRadius(obj.pos, obj.radius) {
+		if t.IsSolid() {
+			game.level.DestroyTile(t)
+		}
+	}
+	demon.Actor.Update(game, obj)
+}
+
+func (demon *Demon) OnCollision(game *Game, obj, other *Object) {
+	//Only the player's shots can hurt it, and not while it's roaring. It doesn't get pushed around by its minions.
+	//Unlike other mobs, it doesn't get a moment of invincibility after each hit.
+	if demon.dead || demon.ai.State() == AI_ALERT || !other.HasColType(CT_PLAYERSHOT) {
+		return
+	}
+	damage := ShotDamage(other, obj)
+	if damage <= 0 {
+		return
+	}
+	demon.health -= damage
+	Emit_Signal(EnemyHurt{Enemy: obj, Archetype: obj.archetype, Source: other, Damage: damage})
+	if demon.health > 0 {
+		audio.PlaySound("enemy_hurt")
+	} else {
+		Emit_Signal(EnemyKilled{Enemy: obj, Archetype: obj.archetype, Source: other, Pos: obj.pos.Clone()})
+	}
+}
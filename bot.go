@@ -0,0 +1,303 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"text/tabwriter"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/audio"
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+const (
+	BOT_SHOOT_RANGE  = 160.0 //Distance within which the bot shoots at monsters
+	BOT_LOVE_RANGE   = 240.0 //Distance within which the bot goes for love drops
+	BOT_FIGHT_RANGE  = 96.0  //Distance the bot keeps from the monster it's fighting
+	BOT_DODGE_RANGE  = 64.0  //Distance within which the bot dodges enemy shots
+	BOT_DODGE_MARGIN = 10.0  //Extra room the bot wants between itself and a passing shot
+	BOT_AVOID_RANGE  = 32.0  //Distance within which the bot backs away from monsters
+	BOT_REPATH_TIME  = 0.5   //Seconds between path searches
+	BOT_STUCK_TIME   = 4.0   //Seconds without getting anywhere before the bot gives up on where it's going
+)
+
+//Plays the game in place of a person by feeding input to the player.
+//It fills the love meter by shooting monsters and picking up their drops, and then hunts down the cat.
+type Bot struct {
+	path       *Path
+	pathTimer  float64
+	roamGoal   *vmath.Vec2f //Random place to head for when there is nothing better to do
+	catHeard   *vmath.Vec2f //Where the cat last meowed. Nil once the bot has checked it out.
+	stuckPos   *vmath.Vec2f
+	stuckTimer float64
+	//What went wrong, for the trial report
+	catUnreachable bool
+	stuck          bool
+}
+
+//Makes the bot play the game instead of the keyboard
+func AttachBot(game *Game) *Bot {
+	bot := &Bot{}
+	game.playerObj.components[0].(*Player).input = bot
+	game.Track(Listen_Signal(func(ev CatMeowed) {
+		bot.catHeard = ev.Pos.Clone()
+	}))
+	return bot
+}
+
+func (bot *Bot) PlayerInput(game *Game, obj *Object) PlayerInput {
+	player := obj.components[0].(*Player)
+	var in PlayerInput
+
+	//Look around
+	var cat, nearestEnemy, nearestLove *Object
+	enemyDist, loveDist := math.Inf(1), math.Inf(1)
+	dodge := vmath.ZeroVec()
+	for objE := game.objects.Front(); objE != nil; objE = objE.Next() {
+		other := objE.Value.(*Object)
+		if other.removeMe {
+			continue
+		}
+		offset := game.level.Displacement(obj.pos, other.pos, false)
+		dist := offset.Length()
+		switch {
+		case other.HasColType(CT_CAT):
+			if game.SquareOnScreen(other.pos.X, other.pos.Y, other.radius) {
+				cat = other
+			}
+		case other.HasColType(CT_ITEM | CT_PICKUP):
+			if dist < loveDist {
+				nearestLove, loveDist = other, dist
+			}
+		case other.HasColType(CT_ENEMYSHOT):
+			if dist < BOT_DODGE_RANGE {
+				dodge.Add(bot.DodgeShot(obj, other, offset))
+			}
+		case other.HasColType(CT_ENEMY) && other.parent == nil:
+			if dist < BOT_AVOID_RANGE && dist > 0.0 {
+				dodge.Sub(offset.Clone().Scale((BOT_AVOID_RANGE - dist) / (BOT_AVOID_RANGE * dist)))
+			}
+			if dist < enemyDist {
+				nearestEnemy, enemyDist = other, dist
+			}
+		}
+	}
+
+	//Pick where to go
+	var goal *vmath.Vec2f
+	wrap := false
+	keepAway := 0.0
+	switch {
+	case player.ascended:
+		wrap = true
+		if cat != nil {
+			bot.catHeard = cat.pos.Clone()
+		}
+		if bot.catHeard != nil {
+			goal = bot.catHeard
+		}
+	case nearestLove != nil && loveDist < BOT_LOVE_RANGE:
+		goal = nearestLove.pos
+	case nearestEnemy != nil:
+		goal, keepAway = nearestEnemy.pos, BOT_FIGHT_RANGE
+	}
+	if goal == nil {
+		if bot.roamGoal == nil {
+			spawn := game.level.FindSpawnPoint()
+			bot.roamGoal = vmath.NewVec(spawn.centerX, spawn.centerY)
+		}
+		goal = bot.roamGoal
+	}
+
+	//Get there
+	move := vmath.ZeroVec()
+	if game.level.Displacement(obj.pos, goal, wrap).Length() > math.Max(keepAway, TILE_SIZE_H) {
+		move = bot.Navigate(game, obj, goal, wrap)
+	} else if goal == bot.roamGoal {
+		bot.roamGoal = nil
+	} else if goal == bot.catHeard && cat == nil {
+		//Nothing here anymore
+		bot.catHeard = nil
+	}
+	if move.Length() > 0.0 {
+		move.Normalize()
+	}
+	move.Add(dodge.Scale(2.0))
+	in.Move = move
+
+	//Shoot whatever can be hit
+	target := nearestEnemy
+	if player.ascended && cat != nil {
+		target = cat
+	}
+	if target != nil {
+		toTarget := target.pos.Clone().Sub(obj.pos)
+		if dist := toTarget.Length(); dist < BOT_SHOOT_RANGE {
+			if raycast := game.level.Raycast(obj.pos.Clone(), toTarget, dist); !raycast.hit || raycast.distance >= dist {
+				in.Aim = toTarget
+			}
+		}
+	}
+	//Let go of charging weapons once they're fully charged
+	if wpn := player.Weapon(); wpn.charge > 0.0 && player.trigger.charge >= wpn.charge {
+		in.Aim = nil
+	}
+
+	bot.CheckProgress(game, obj)
+	return in
+}
+
+//Returns the direction along a path to the goal, searching for a new one every so often
+func (bot *Bot) Navigate(game *Game, obj *Object, goal *vmath.Vec2f, wrap bool) *vmath.Vec2f {
+	bot.pathTimer -= game.deltaTime
+	if bot.path == nil || bot.pathTimer <= 0.0 || bot.path.wrap != wrap || game.level.Displacement(bot.path.Goal(), goal, wrap).Length() > TILE_SIZE {
+		bot.pathTimer = BOT_REPATH_TIME
+		bot.path = game.level.FindPath(obj.pos, goal, obj.radius, wrap)
+		if bot.path == nil {
+			if goal == bot.catHeard {
+				bot.catUnreachable = true
+				bot.catHeard = nil
+			} else if goal == bot.roamGoal {
+				bot.roamGoal = nil
+			}
+			return vmath.ZeroVec()
+		}
+	}
+	if dir := bot.path.Steer(game.level, obj.pos, obj.radius); dir != nil {
+		return dir
+	}
+	return vmath.ZeroVec()
+}
+
+//Returns the direction to step in to get out of the way of the shot, or zero if it's going to miss
+func (bot *Bot) DodgeShot(obj, shotObj *Object, offset *vmath.Vec2f) *vmath.Vec2f {
+	shot, ok := shotObj.components[0].(*Shot)
+	if !ok || shot.vel.Length() == 0.0 {
+		return vmath.ZeroVec()
+	}
+	heading := shot.vel.Clone().Normalize()
+	//Offset from the shot to the bot, split into the part along the shot's path and the part across it
+	toBot := offset.Clone().Scale(-1.0)
+	along := vmath.VecDot(toBot, heading)
+	if along <= 0.0 {
+		return vmath.ZeroVec() //Going away
+	}
+	across := toBot.Sub(heading.Clone().Scale(along))
+	if across.Length() > obj.radius+shotObj.radius+BOT_DODGE_MARGIN {
+		return vmath.ZeroVec()
+	}
+	if across.Length() == 0.0 {
+		across = vmath.NewVec(-heading.Y, heading.X)
+	}
+	return across.Normalize()
+}
+
+//Forgets about the current destination if the bot hasn't gotten anywhere in a while
+func (bot *Bot) CheckProgress(game *Game, obj *Object) {
+	if bot.stuckPos == nil || bot.stuckPos.Clone().Sub(obj.pos).Length() > TILE_SIZE {
+		bot.stuckPos = obj.pos.Clone()
+		bot.stuckTimer = 0.0
+		return
+	}
+	bot.stuckTimer += game.deltaTime
+	if bot.stuckTimer > BOT_STUCK_TIME {
+		bot.stuck = true
+		bot.stuckTimer = 0.0
+		bot.path = nil
+		bot.roamGoal = nil
+	}
+}
+
+//Set while the bot is playing so that it doesn't unlock achievements or set records in the player's saves
+var __botPlaying bool
+
+//How one mission played by the bot went
+type BotTrial struct {
+	mission   int
+	seed      int64
+	par       float64 //Par time adjusted for the difficulty
+	completed bool
+	ascended  bool
+	time      float64
+	stats     *Stats
+	bot       *Bot
+}
+
+//Plays the mission with the bot as fast as possible, without a window, until the cat is dead or time runs out.
+//A display is still needed, since Ebiten connects to one as soon as the program starts.
+func RunBotTrial(mission int, seed int64, timeLimit float64) *BotTrial {
+	rand.Seed(seed)
+	game := NewGame(mission)
+	ChangeAppState(game)
+	trial := &BotTrial{mission: mission, seed: seed, par: float64(game.mission.parTime), stats: game.stats, bot: game.playerObj.components[0].(*Player).input.(*Bot)}
+	game.Track(Listen_Signal(func(ev PlayerAscended) {
+		trial.ascended = true
+	}))
+	//The tick limit keeps a game that never starts from hanging the trial
+	for ticks := 0; game.fade != FM_FADE_OUT && game.elapsedTime < timeLimit && ticks < int((timeLimit+10.0)/FRAMERATE); ticks++ {
+		game.Update(FRAMERATE)
+	}
+	trial.completed = game.fade == FM_FADE_OUT
+	trial.time = game.elapsedTime
+	return trial
+}
+
+//Sums up what happened, pointing out anything that suggests the mission is broken
+func (bt *BotTrial) Verdict() string {
+	switch {
+	case bt.completed && bt.time < bt.par:
+		return "PAR"
+	case bt.completed:
+		return "SLOW"
+	case bt.bot.catUnreachable:
+		return "CAT UNREACHABLE"
+	case bt.ascended:
+		return "CAT NOT FOUND"
+	case bt.bot.stuck && bt.stats.LoveCollected == 0:
+		return "STUCK"
+	}
+	return "NO ASCENSION"
+}
+
+//Has the bot play each of the missions once for every seed, and prints how it went.
+//See RunBotTrial about the display.
+func RunBotTrials(missionNums []int, firstSeed int64, count int, timeScale float64) {
+	__botPlaying = true
+	audio.MuteSfx, audio.MuteMusic = true, true
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "DIFFICULTY %s\n", __difficulty)
+	fmt.Fprintln(w, "MISSION\tSEED\tTIME\tPAR\tHURT\tDESCENTS\tKILLS\tRESULT")
+	for _, m := range missionNums {
+		beaten := 0
+		for i := 0; i < count; i++ {
+			seed := firstSeed + int64(i)
+			par := __difficulty.Scale(&missions[m]).parTime
+			trial := RunBotTrial(m, seed, float64(par)*timeScale)
+			if trial.completed && trial.time < trial.par {
+				beaten++
+			}
+			fmt.Fprintf(w, "%d\t%d\t%.1f\t%d\t%d\t%d\t%d\t%s\n", m, seed, trial.time, par,
+				trial.stats.TimesHurt, trial.stats.Descents, trial.stats.TotalKills(), trial.Verdict())
+		}
+		fmt.Fprintf(w, "%d\tBEAT PAR %d/%d\n", m, beaten, count)
+		w.Flush()
+	}
+}
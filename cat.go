@@ -26,9 +26,24 @@ import (
 	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
 )
 
+const (
+	CAT_FLEE_RANGE_MIN = 96.0  //Distance at which the least skilled cat starts running from the ascended player
+	CAT_FLEE_RANGE_MAX = 256.0 //Same as above, for the most skilled cat
+	CAT_CALM_MARGIN    = 64.0  //Extra distance the player must be before the cat stops running
+	CAT_WARP_DELAY_MIN = 0.1   //Time the most skilled cat pushes against the edge of the map before warping
+	CAT_WARP_DELAY_MAX = 1.0   //Same as above, for the least skilled cat
+	CAT_WALL_AVOIDANCE = 0.75  //How strongly a fleeing cat steers away from nearby walls
+	CAT_PATH_RETRY     = 1.0   //Time the cat wanders aimlessly after failing to find a path before it picks another goal
+	CAT_STUCK_TIME     = 5.0   //Time the cat can go without moving much before it's respawned
+)
+
 type Cat struct {
 	Mob
 	meowTimer    float64
+	skill        float64      //Copied from the mission
+	wanderGoal   *vmath.Vec2f //Open spot the cat is running to when it's not being chased
+	pathRetry    float64      //Time left before the cat looks for a path again
+	warpTimer    float64      //Time spent pushing against the edge of the map
 	stuckTimer   float64
 	walkDistance float64
 }
@@ -58,43 +73,176 @@ func AddCat(game *Game, x, y float64) (*Cat, *Object) {
 			},
 		},
 		meowTimer: rand.Float64() * 5.0,
+		skill:     game.mission.catSkill,
 	}
 	obj := &Object{
 		pos: vmath.NewVec(x, y), radius: 6.0, colType: CT_CAT,
 		sprites:    []*Sprite{sprCatRunLeft[0]},
 		components: []Component{cat},
 	}
+	cat.ai = cat.NewAI(game, obj)
 	game.AddObject(obj)
 	//Move in random direction
 	d := vmath.RandomDirection()
 	cat.Move(d.X, d.Y)
-	//Spawn poofs
+	AddCatPoofs(game, x, y)
+	return cat, obj
+}
+
+//Spawns a ring of poofs around the position
+func AddCatPoofs(game *Game, x, y float64) {
 	ang := rand.Float64() * math.Pi * 2.0
 	for i := 0.0; i < math.Pi*2.0; i += math.Pi / 4.0 {
 		ox := math.Cos(ang+i) * 12.0
 		oy := math.Sin(ang+i) * 12.0
 		AddPoof(game, x+ox, y+oy)
 	}
-	return cat, obj
+}
+
+//Distance from the ascended player at which the cat starts running away
+func (cat *Cat) FleeRange() float64 {
+	return CAT_FLEE_RANGE_MIN + (CAT_FLEE_RANGE_MAX-CAT_FLEE_RANGE_MIN)*cat.skill
+}
+
+//Returns true if the player is ascended and close enough to be a threat
+func (cat *Cat) Threatened(game *Game, margin float64) bool {
+	player := game.playerObj.components[0].(*Player)
+	return player.ascended && cat.distToPlayer < cat.FleeRange()+margin
+}
+
+//The cat runs between open spots until the ascended player comes near, and then runs away from them
+func (cat *Cat) NewAI(game *Game, obj *Object) *StateMachine {
+	baseSpeed := cat.maxSpeed
+	sm := NewStateMachine(AI_WANDER)
+	sm.Define(AI_WANDER, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			cat.wanderGoal = nil
+		},
+		Update: func(game *Game, obj *Object) {
+			if cat.Threatened(game, 0.0) {
+				sm.Change(game, obj, AI_FLEE)
+				return
+			}
+			//Run around aimlessly for a bit after a failed search, instead of searching again every frame
+			if cat.pathRetry > 0.0 {
+				cat.pathRetry -= game.deltaTime
+				if cat.movement.X == 0.0 && cat.movement.Y == 0.0 {
+					d := vmath.RandomDirection()
+					cat.Move(d.X, d.Y)
+				}
+				cat.Wander(game, obj, 64.0, math.Pi)
+				return
+			}
+			if cat.wanderGoal == nil {
+				spawn := game.level.FindSpawnPoint()
+				cat.wanderGoal = vmath.NewVec(spawn.centerX, spawn.centerY)
+			}
+			dir := cat.NavigateTo(game, obj, cat.wanderGoal)
+			if dir.X == 0.0 && dir.Y == 0.0 {
+				//Arrived, or there's no way to get there
+				if game.level.Displacement(obj.pos, cat.wanderGoal, false).Length() > TILE_SIZE {
+					cat.pathRetry = CAT_PATH_RETRY
+				}
+				cat.wanderGoal = nil
+				return
+			}
+			cat.Move(dir.X, dir.Y)
+		},
+	})
+	sm.Define(AI_FLEE, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			cat.path = nil
+			cat.warpTimer = 0.0
+			cat.maxSpeed = baseSpeed * (0.9 + 0.2*cat.skill)
+		},
+		Update: func(game *Game, obj *Object) {
+			if !cat.Threatened(game, CAT_CALM_MARGIN) {
+				sm.Change(game, obj, AI_WANDER)
+				return
+			}
+			dir := cat.FleeDirection(game, obj)
+			cat.Move(dir.X, dir.Y)
+			cat.TryWarp(game, obj)
+		},
+		Exit: func(game *Game, obj *Object) {
+			cat.maxSpeed = baseSpeed
+		},
+	})
+	sm.Define(AI_DYING, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			cat.Move(0.0, 0.0)
+			cat.dead = true
+			obj.layer = RL_OVERLAY //Rise above everything else while dying
+			audio.PlaySound("cat_die")
+			cat.currAnim = &Anim{
+				frames: sprCatDie,
+				speed:  0.5,
+				callback: func(anm *Anim) {
+					if anm.finished {
+						Emit_Signal(CatDied{Cat: obj, Pos: obj.pos.Clone()})
+					}
+				},
+			}
+		},
+		Update: func(game *Game, obj *Object) {
+			cat.Move(0.0, 0.0) //Other cats can bump it around
+		},
+	})
+	return sm
+}
+
+//Returns the direction that leads away from the player, steering clear of walls so the cat doesn't get cornered
+func (cat *Cat) FleeDirection(game *Game, obj *Object) *vmath.Vec2f {
+	dir := game.catField.FleeDirection(obj.pos)
+	if dir.X == 0.0 && dir.Y == 0.0 {
+		//Nowhere better to go according to the field, so just get away
+		dir = game.level.Displacement(game.playerObj.pos, obj.pos, true).Normalize()
+	}
+	//Only walls count here. The edges of the map are a way out.
+	if hit, normal, tile := game.level.SphereIntersects(obj.pos, obj.radius*2.5); hit && tile != nil {
+		dir.Add(normal.Scale(CAT_WALL_AVOIDANCE))
+	}
+	return dir
+}
+
+//Teleports the cat to the other side of the map once it's been running against the edge long enough
+func (cat *Cat) TryWarp(game *Game, obj *Object) {
+	if cat.skill <= 0.0 || !game.level.AtWarpEdge(obj.pos, obj.radius, cat.movement.X, cat.movement.Y) {
+		cat.warpTimer = 0.0
+		return
+	}
+	cat.warpTimer += game.deltaTime
+	if cat.warpTimer < CAT_WARP_DELAY_MAX+(CAT_WARP_DELAY_MIN-CAT_WARP_DELAY_MAX)*cat.skill {
+		return
+	}
+	if dest := game.level.WarpDestination(obj.pos, obj.radius, cat.movement.X, cat.movement.Y); dest != nil {
+		AddCatPoofs(game, obj.pos.X, obj.pos.Y)
+		obj.pos.X, obj.pos.Y = dest.X, dest.Y
+		AddCatPoofs(game, obj.pos.X, obj.pos.Y)
+		cat.warpTimer = 0.0
+		cat.path = nil
+	}
 }
 
 func (cat *Cat) Update(game *Game, obj *Object) {
 	//Another fail-safe. Apparently if there are too many cats on screen at once they will occasionally be stuck in NaNspace
-	if math.IsNaN(cat.walkDistance) {
-		obj.removeMe = true
-		spawn := game.level.FindOffscreenSpawnPoint(game)
-		AddCat(game, spawn.centerX, spawn.centerY)
+	if math.IsNaN(cat.walkDistance) && !obj.removeMe {
+		cat.Respawn(game, obj)
+		return
 	}
 
-	if !cat.dead {
-		cat.Wander(game, obj, 64.0, math.Pi)
+	//Death
+	if cat.health <= 0 && !cat.dead {
+		cat.ai.Change(game, obj, AI_DYING)
+	}
 
+	if !cat.dead {
 		cat.meowTimer += game.deltaTime
 		if cat.meowTimer > 5.0 {
 			audio.PlaySoundAttenuated("cat_meow", 256.0, obj.pos, game.camMin, game.camMax)
+			Emit_Signal(CatMeowed{Cat: obj, Pos: obj.pos.Clone()})
 			cat.meowTimer = 0.0
 		}
-
 		//Flip the sprites in the animation to match movement direction
 		if cat.currAnim != nil {
 			if cat.movement.X > 0 {
@@ -103,24 +251,6 @@ func (cat *Cat) Update(game *Game, obj *Object) {
 				cat.currAnim.frames = sprCatRunLeft
 			}
 		}
-	} else {
-		cat.Move(0.0, 0.0)
-	}
-
-	//Death
-	if cat.health <= 0 && !cat.dead {
-		cat.Move(0.0, 0.0)
-		cat.dead = true
-		audio.PlaySound("cat_die")
-		cat.currAnim = &Anim{
-			frames: sprCatDie,
-			speed:  0.5,
-			callback: func(anm *Anim) {
-				if anm.finished {
-					Emit_Signal(SIGNAL_CAT_DIE, obj, nil)
-				}
-			},
-		}
 	}
 
 	walkDiff := obj.pos.Clone()
@@ -128,31 +258,33 @@ func (cat *Cat) Update(game *Game, obj *Object) {
 	cat.Mob.Update(game, obj)
 	cat.Actor.Update(game, obj)
 
-	walkDiff.Sub(obj.pos.Clone())
+	walkDiff.Sub(obj.pos)
 	walkDelta := walkDiff.Length()
 	cat.walkDistance += walkDelta //Keep track of how much the cat moves
 
-	//Respawns the cat when it spends 10 seconds without moving much
+	//Respawns the cat when it spends too long without moving much
 	//A failsafe in case I haven't actually fixed that elusive bug
-	if walkDelta < 8.0/60.0 {
+	if walkDelta < 8.0/60.0 && !cat.dead {
 		cat.stuckTimer += game.deltaTime
-		if cat.stuckTimer > 5.0 {
-			//Spawn poofs
-			ang := rand.Float64() * math.Pi * 2.0
-			for i := 0.0; i < math.Pi*2.0; i += math.Pi / 4.0 {
-				ox := math.Cos(ang+i) * 12.0
-				oy := math.Sin(ang+i) * 12.0
-				AddPoof(game, obj.pos.X+ox, obj.pos.Y+oy)
-			}
-			obj.removeMe = true
-			spawn := game.level.FindOffscreenSpawnPoint(game)
-			AddCat(game, spawn.centerX, spawn.centerY)
+		if cat.stuckTimer > CAT_STUCK_TIME && !obj.removeMe {
+			AddCatPoofs(game, obj.pos.X, obj.pos.Y)
+			cat.Respawn(game, obj)
 		}
 	} else {
 		cat.stuckTimer = 0.0
 	}
 }
 
+//Replaces the cat with a new one somewhere off screen
+func (cat *Cat) Respawn(game *Game, obj *Object) {
+	spawn := game.level.FindOffscreenSpawnPoint(game)
+	if spawn == nil {
+		spawn = game.level.FindSpawnPoint()
+	}
+	obj.removeMe = true
+	AddCat(game, spawn.centerX, spawn.centerY)
+}
+
 var __dudShots int
 
 func (cat *Cat) OnCollision(game *Game, obj, other *Object) {
@@ -168,7 +300,7 @@ func (cat *Cat) OnCollision(game *Game, obj, other *Object) {
 	} else if other.HasColType(CT_PLAYERSHOT) {
 		__dudShots++
 		if __dudShots%16 == 0 {
-			Emit_Signal(SIGNAL_CAT_RULE, obj, nil)
+			Emit_Signal(CatRule{Cat: obj})
 		}
 	}
 }
@@ -0,0 +1,61 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+//Keeps track of how many objects of each archetype are on the playing field.
+//The game updates it whenever objects are added or removed, so spawners never have to count by hand.
+type Census struct {
+	counts map[string]int
+}
+
+func NewCensus() *Census {
+	return &Census{
+		counts: make(map[string]int),
+	}
+}
+
+//Counts the object if it belongs to an archetype
+func (cs *Census) Add(obj *Object) {
+	if obj.archetype != "" {
+		cs.counts[obj.archetype]++
+	}
+}
+
+//Uncounts the object if it belongs to an archetype
+func (cs *Census) Remove(obj *Object) {
+	if obj.archetype != "" {
+		cs.counts[obj.archetype]--
+		if cs.counts[obj.archetype] <= 0 {
+			delete(cs.counts, obj.archetype)
+		}
+	}
+}
+
+//Returns the number of objects of the given archetype that are in the game
+func (cs *Census) Count(archetype string) int {
+	return cs.counts[archetype]
+}
+
+//Returns the number of archetype instances in the game, regardless of type
+func (cs *Census) Total() int {
+	total := 0
+	for _, n := range cs.counts {
+		total += n
+	}
+	return total
+}
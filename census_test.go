@@ -0,0 +1,107 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"container/list"
+	"testing"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+func newCensusGame() *Game {
+	return &Game{
+		objects: list.New(),
+		census:  NewCensus(),
+	}
+}
+
+func addCensusObject(game *Game, archetype string) *Object {
+	return game.AddObject(&Object{pos: vmath.ZeroVec(), archetype: archetype})
+}
+
+func TestCensusAddRemove(t *testing.T) {
+	cs := NewCensus()
+	knight := &Object{archetype: "knight"}
+	worm := &Object{archetype: "worm"}
+	plain := &Object{}
+
+	cs.Add(knight)
+	cs.Add(&Object{archetype: "knight"})
+	cs.Add(worm)
+	cs.Add(plain)
+	if n := cs.Count("knight"); n != 2 {
+		t.Errorf("knight count is %d after adding two, want 2", n)
+	}
+	if n := cs.Total(); n != 3 {
+		t.Errorf("total is %d, want 3 (objects without an archetype don't count)", n)
+	}
+
+	cs.Remove(knight)
+	cs.Remove(worm)
+	cs.Remove(plain)
+	if n := cs.Count("knight"); n != 1 {
+		t.Errorf("knight count is %d after removing one, want 1", n)
+	}
+	if n := cs.Count("worm"); n != 0 {
+		t.Errorf("worm count is %d after removing it, want 0", n)
+	}
+	if n := cs.Total(); n != 1 {
+		t.Errorf("total is %d, want 1", n)
+	}
+}
+
+func TestCensusRemoveMe(t *testing.T) {
+	game := newCensusGame()
+	first := addCensusObject(game, "knight")
+	addCensusObject(game, "knight")
+	addCensusObject(game, "barrel")
+	if n := game.census.Count("knight"); n != 2 {
+		t.Fatalf("knight count is %d after AddObject, want 2", n)
+	}
+
+	first.removeMe = true
+	game.RemoveObjects(game.FlaggedObjects())
+	if n := game.census.Count("knight"); n != 1 {
+		t.Errorf("knight count is %d after removing one through removeMe, want 1", n)
+	}
+	if n := game.census.Total(); n != game.objects.Len() {
+		t.Errorf("census total is %d but the game has %d objects", n, game.objects.Len())
+	}
+}
+
+func TestCensusRemoveMeChildren(t *testing.T) {
+	game := newCensusGame()
+	head := addCensusObject(game, "worm")
+	for i := 0; i < 3; i++ {
+		game.AddObject(head.AttachChild(&Object{archetype: "wormseg"}, float64(i)*8.0, 0.0))
+	}
+	if n := game.census.Total(); n != 4 {
+		t.Fatalf("census total is %d after adding a worm, want 4", n)
+	}
+
+	//Children are removed along with their parent
+	head.removeMe = true
+	game.RemoveObjects(game.FlaggedObjects())
+	if n := game.census.Total(); n != 0 {
+		t.Errorf("census total is %d after removing the worm, want 0", n)
+	}
+	if n := game.objects.Len(); n != 0 {
+		t.Errorf("game has %d objects after removing the worm, want 0", n)
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+//Aggregates the telemetry logs written by the game's -telemetry option into per-mission statistics.
+//
+//Usage: telemetry-stats [-cell size] [-top n] <log files or directories>...
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Mirrors TelemetryEntry in the game's telemetry.go
+type Entry struct {
+	Time        time.Time   `json:"time"`
+	MissionTime float64     `json:"missionTime"`
+	Mission     int         `json:"mission"`
+	Stage       int         `json:"stage,omitempty"`
+	Difficulty  string      `json:"difficulty"`
+	Seed        int64       `json:"seed"`
+	Event       string      `json:"event"`
+	Pos         *[2]float64 `json:"pos,omitempty"`
+	Archetype   string      `json:"archetype,omitempty"`
+	Value       int         `json:"value,omitempty"`
+	Delta       int         `json:"delta,omitempty"`
+}
+
+type Cell struct {
+	X, Y int
+}
+
+//Stats are kept apart for each mission on each difficulty. Every stage of endless mode counts as its own mission.
+type MissionKey struct {
+	Mission    int
+	Stage      int
+	Difficulty string
+}
+
+func (mk MissionKey) String() string {
+	name := fmt.Sprintf("Mission %d", mk.Mission)
+	if mk.Stage > 0 {
+		name = fmt.Sprintf("Endless stage %d", mk.Stage)
+	}
+	if mk.Difficulty != "" {
+		name += " on " + mk.Difficulty
+	}
+	return name
+}
+
+type MissionStats struct {
+	attempts     int
+	parTime      int
+	times        []float64 //Completion times in seconds
+	beatPar      int
+	damage       int
+	hurts        int
+	damageBy     map[string]int //Love lost by what hurt the player
+	damageAt     map[Cell]int   //Love lost by map area
+	kills        map[string]int
+	descents     int
+	warps        int
+	catRuleHints int
+}
+
+func NewMissionStats() *MissionStats {
+	return &MissionStats{
+		damageBy: make(map[string]int),
+		damageAt: make(map[Cell]int),
+		kills:    make(map[string]int),
+	}
+}
+
+var cellSize = flag.Float64("cell", 64.0, "Size in pixels of the map areas that damage is grouped into")
+var topN = flag.Int("top", 5, "Number of entries to show in the damage breakdowns")
+
+func main() {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: telemetry-stats [-cell size] [-top n] <log files or directories>...")
+		os.Exit(2)
+	}
+
+	missions := make(map[MissionKey]*MissionStats)
+	files := 0
+	for _, arg := range flag.Args() {
+		for _, path := range findLogs(arg) {
+			if err := readLog(path, missions); err != nil {
+				log.Println("Skipping ", path, ": ", err)
+				continue
+			}
+			files++
+		}
+	}
+	fmt.Printf("Read %d log(s)\n", files)
+
+	keys := make([]MissionKey, 0, len(missions))
+	for k := range missions {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Mission != b.Mission {
+			return a.Mission < b.Mission
+		}
+		if a.Stage != b.Stage {
+			return a.Stage < b.Stage
+		}
+		return a.Difficulty < b.Difficulty
+	})
+	for _, k := range keys {
+		printMission(k, missions[k])
+	}
+}
+
+//Returns the path itself, or the .jsonl files inside of it if it's a directory
+func findLogs(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if !info.IsDir() {
+		return []string{path}
+	}
+	matches, err := filepath.Glob(filepath.Join(path, "*.jsonl"))
+	if err != nil {
+		log.Println(err)
+	}
+	return matches
+}
+
+func readLog(path string, missions map[MissionKey]*MissionStats) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		key := MissionKey{e.Mission, e.Stage, e.Difficulty}
+		ms, ok := missions[key]
+		if !ok {
+			ms = NewMissionStats()
+			missions[key] = ms
+		}
+		switch e.Event {
+		case "mission_start":
+			ms.attempts++
+			ms.parTime = e.Value
+		case "complete":
+			ms.times = append(ms.times, e.MissionTime)
+			if e.Value != 0 {
+				ms.beatPar++
+			}
+		case "player_hurt":
+			ms.hurts++
+			ms.damage += e.Value
+			name := e.Archetype
+			if name == "" {
+				name = "unknown"
+			}
+			ms.damageBy[name] += e.Value
+			if e.Pos != nil {
+				ms.damageAt[Cell{int(e.Pos[0] / *cellSize), int(e.Pos[1] / *cellSize)}] += e.Value
+			}
+		case "kill":
+			ms.kills[e.Archetype]++
+		case "descend":
+			ms.descents++
+		case "warp":
+			ms.warps++
+		case "cat_rule":
+			ms.catRuleHints++
+		}
+	}
+	return scanner.Err()
+}
+
+func printMission(key MissionKey, ms *MissionStats) {
+	fmt.Printf("\n== %s ==\n", key)
+	fmt.Printf("Attempts: %d, completed: %d", ms.attempts, len(ms.times))
+	if ms.parTime > 0 {
+		fmt.Printf(", beat par (%s): %d", formatTime(float64(ms.parTime)), ms.beatPar)
+	}
+	fmt.Println()
+	if len(ms.times) > 0 {
+		sort.Float64s(ms.times)
+		sum := 0.0
+		for _, t := range ms.times {
+			sum += t
+		}
+		fmt.Printf("Completion time: median %s, mean %s, best %s, worst %s\n",
+			formatTime(median(ms.times)), formatTime(sum/float64(len(ms.times))),
+			formatTime(ms.times[0]), formatTime(ms.times[len(ms.times)-1]))
+	}
+	if ms.attempts > 0 {
+		fmt.Printf("Per attempt: %.1f love lost in %.1f hits, %.1f descents, %.1f warps, %.1f cat hints\n",
+			float64(ms.damage)/float64(ms.attempts), float64(ms.hurts)/float64(ms.attempts),
+			float64(ms.descents)/float64(ms.attempts), float64(ms.warps)/float64(ms.attempts),
+			float64(ms.catRuleHints)/float64(ms.attempts))
+	}
+
+	if len(ms.damageBy) > 0 {
+		fmt.Println("Damage taken from:")
+		for _, kv := range sortedByValue(ms.damageBy, *topN) {
+			fmt.Printf("  %-12s %5d (%.0f%%)\n", kv.key, kv.value, 100.0*float64(kv.value)/float64(ms.damage))
+		}
+	}
+	if len(ms.damageAt) > 0 {
+		cells := make(map[string]int, len(ms.damageAt))
+		for c, v := range ms.damageAt {
+			x, y := float64(c.X)**cellSize, float64(c.Y)**cellSize
+			cells[fmt.Sprintf("(%.0f,%.0f)", x, y)] = v
+		}
+		fmt.Printf("Damage taken at (top left of %.0fpx areas):\n", *cellSize)
+		for _, kv := range sortedByValue(cells, *topN) {
+			fmt.Printf("  %-12s %5d\n", kv.key, kv.value)
+		}
+	}
+	if len(ms.kills) > 0 {
+		fmt.Println("Kills:")
+		for _, kv := range sortedByValue(ms.kills, 0) {
+			fmt.Printf("  %-12s %5d\n", kv.key, kv.value)
+		}
+	}
+}
+
+type keyValue struct {
+	key   string
+	value int
+}
+
+//Returns the map's entries from highest value to lowest, limited to max entries if max > 0
+func sortedByValue(m map[string]int, max int) []keyValue {
+	kvs := make([]keyValue, 0, len(m))
+	for k, v := range m {
+		kvs = append(kvs, keyValue{k, v})
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		if kvs[i].value == kvs[j].value {
+			return kvs[i].key < kvs[j].key
+		}
+		return kvs[i].value > kvs[j].value
+	})
+	if max > 0 && len(kvs) > max {
+		kvs = kvs[:max]
+	}
+	return kvs
+}
+
+//Expects a sorted slice
+func median(values []float64) float64 {
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2.0
+	}
+	return values[mid]
+}
+
+func formatTime(seconds float64) string {
+	return fmt.Sprintf("%d:%02d", int(seconds)/60, int(seconds)%60)
+}
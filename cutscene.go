@@ -33,8 +33,25 @@ type Cutscene struct {
 	dialog   []string
 	music    string
 	voice    string
+	voices   []string //Voices of the first lines of dialog, for scenes with more than one speaker. The rest use voice.
 }
 
+//Returns the voice that the line of dialog is spoken in
+func (cs *Cutscene) Voice(line int) string {
+	if line < len(cs.voices) {
+		return cs.voices[line]
+	}
+	return cs.voice
+}
+
+//Cutscenes that come after the last mission, counting from len(missions)
+const (
+	SCENE_DEMON    = iota //The demon shows itself, and the fight with it begins
+	SCENE_GOOD_END        //Plays instead of the above if every par time was beaten
+	SCENE_BOSS_WIN
+	SCENE_BOSS_LOSE
+)
+
 var cutscenes []Cutscene
 
 func init() {
@@ -172,10 +189,37 @@ func init() {
 			music: "rescue",
 			voice: "voice",
 		},
+		{
+			bodyType: BODY_CAT,
+			faces:    []FaceType{FACE_NONE, FACE_NONE, FACE_NONE, FACE_EMPTY_SAD, FACE_EMPTY_TALK, FACE_SMILE},
+			dialog: []string{
+				"NO...NO! THIS CANNOT BE!",
+				"I AM GOD! I AM...",
+				"I WILL...COME...BACK...",
+				"...",
+				"IT'S GONE. I CAN FEEL MY  BODY AGAIN.",
+				"THANK YOU, FRIEND. LET'S  NOT DO THAT AGAIN.",
+			},
+			music:  "rescue",
+			voice:  "voice",
+			voices: []string{"evil_voice", "evil_voice", "evil_voice"}, //The demon gets the first few lines before the cat takes over
+		},
+		{
+			bodyType: BODY_NONE,
+			faces:    []FaceType{FACE_NONE},
+			dialog: []string{
+				"HAHAHAHAHAHAHA!",
+				"DID YOU REALLY THINK YOU  COULD HURT ME?",
+				"THE UNIVERSE IS MINE NOW.",
+			},
+			music: "him",
+			voice: "evil_voice",
+		},
 	}
 }
 
 type CutsceneState struct {
+	SignalScope
 	feles        *Object
 	felesBody    BodyType
 	cutscene     *Cutscene
@@ -195,7 +239,7 @@ func NewCutsceneState(sceneNum int) *CutsceneState {
 	state := new(CutsceneState)
 
 	//Change ending if player beat all par times
-	if sceneNum >= len(missions) {
+	if sceneNum == len(missions)+SCENE_DEMON {
 		for _, m := range missions {
 			if !m.goodEndFlag {
 				goto skip
@@ -218,7 +262,7 @@ func NewCutsceneState(sceneNum int) *CutsceneState {
 	for i, s := range state.cutscene.dialog {
 		state.dialog[i] = GenerateText(s, image.Rect(8, 8, dialogBox.Width()-8, dialogBox.Height()-8))
 		state.dialog[i].fillPos = 0
-		state.dialog[i].fillSound = state.cutscene.voice
+		state.dialog[i].fillSound = state.cutscene.Voice(i)
 		if i > 0 {
 			state.dialog[i].visible = false
 		}
@@ -289,12 +333,14 @@ func (ct *CutsceneState) Update(deltaTime float64) {
 		if ct.transTimer > CUTSCENE_FADE_SPEED {
 			ct.transTimer = 0.0
 			if ct.transition == FM_FADE_OUT {
-				if ct.nextMission >= 0 && ct.nextMission < len(missions) {
+				if ct.nextMission >= 0 && ct.nextMission <= len(missions)+SCENE_DEMON {
+					//The scene after the last mission leads into the fight with the demon
 					ChangeAppState(NewGame(ct.nextMission))
 				} else {
 					ts := new(TitleScreen)
-					ts.badEnd = (ct.nextMission == len(missions))
-					ts.goodEnd = (ct.nextMission == len(missions)+1)
+					ts.badEnd = (ct.nextMission == len(missions)+SCENE_BOSS_LOSE)
+					ts.goodEnd = (ct.nextMission == len(missions)+SCENE_GOOD_END)
+					ts.bossEnd = (ct.nextMission == len(missions)+SCENE_BOSS_WIN)
 					ChangeAppState(ts)
 				}
 			} else {
@@ -305,7 +351,7 @@ func (ct *CutsceneState) Update(deltaTime float64) {
 }
 
 func (ct *CutsceneState) Draw(screen *ebiten.Image) {
-	if ct.nextMission == len(missions) {
+	if ct.nextMission == len(missions)+SCENE_DEMON || ct.nextMission == len(missions)+SCENE_BOSS_LOSE {
 		ct.DrawEvilBackground(screen, math.Sin(ct.elapsedTime)*80.0, math.Cos(ct.elapsedTime)*80.0, (math.Cos(ct.elapsedTime+math.Pi/6.0)*0.4)+1.25)
 		ct.DrawEvilBackground(screen, math.Sin(ct.elapsedTime)*64.0, math.Cos(ct.elapsedTime)*64.0, (math.Sin(ct.elapsedTime+math.Pi/3.0)*0.25)+1.5)
 	}
@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+type Difficulty int
+
+const (
+	DIFF_GENTLE Difficulty = iota
+	DIFF_NORMAL
+	DIFF_CRUEL
+	DIFF_COUNT
+)
+
+//Multipliers applied to a mission's settings. Normal difficulty leaves everything as it is.
+type DifficultyDef struct {
+	name         string
+	loveQuota    float64
+	spawnCaps    float64 //Maximum number of each kind of monster on the field
+	knightSpeed  float64
+	catHealth    float64
+	parTime      float64
+	hurtDamage   float64 //Love lost when the player gets hit
+	waveInterval float64 //Time between the director's waves of respawned enemies
+}
+
+var difficultyDefs = [DIFF_COUNT]DifficultyDef{
+	DIFF_GENTLE: {name: "GENTLE", loveQuota: 0.75, spawnCaps: 0.7, knightSpeed: 0.85, catHealth: 0.6, parTime: 1.5, hurtDamage: 0.5, waveInterval: 1.4},
+	DIFF_NORMAL: {name: "NORMAL", loveQuota: 1.0, spawnCaps: 1.0, knightSpeed: 1.0, catHealth: 1.0, parTime: 1.0, hurtDamage: 1.0, waveInterval: 1.0},
+	DIFF_CRUEL:  {name: "CRUEL", loveQuota: 1.25, spawnCaps: 1.3, knightSpeed: 1.15, catHealth: 1.5, parTime: 0.8, hurtDamage: 1.5, waveInterval: 0.7},
+}
+
+//Difficulty used for new games. It's chosen on the title screen.
+var __difficulty = DIFF_NORMAL
+
+func (d Difficulty) String() string {
+	if d < 0 || d >= DIFF_COUNT {
+		return difficultyDefs[DIFF_NORMAL].name
+	}
+	return difficultyDefs[d].name
+}
+
+//Returns the difficulty with the given name, ignoring case. The second value is false if there isn't one.
+func ParseDifficulty(name string) (Difficulty, bool) {
+	for i, def := range difficultyDefs {
+		if strings.EqualFold(def.name, name) {
+			return Difficulty(i), true
+		}
+	}
+	return DIFF_NORMAL, false
+}
+
+//Returns the next harder difficulty, going back to the easiest after the hardest
+func (d Difficulty) Next() Difficulty {
+	return (d + 1) % DIFF_COUNT
+}
+
+//Returns a copy of the mission with its settings scaled for the difficulty. The original is left untouched.
+func (d Difficulty) Scale(mis *Mission) *Mission {
+	def := &difficultyDefs[d]
+	scaled := *mis
+	scaled.loveQuota = max(1, int(math.Round(float64(mis.loveQuota)*def.loveQuota)))
+	scaled.catHealth = max(1, int(math.Round(float64(mis.catHealth)*def.catHealth)))
+	scaled.parTime = int(math.Round(float64(mis.parTime) * def.parTime))
+	scaled.spawns = make([]SpawnCap, len(mis.spawns))
+	for i, sc := range mis.spawns {
+		scaled.spawns[i] = sc
+		//Barrels aren't monsters, so there are as many of them on every difficulty
+		if arch := archetypes[sc.archetype]; arch == nil || arch.behavior != "barrel" {
+			scaled.spawns[i].max = int(math.Round(float64(sc.max) * def.spawnCaps))
+		}
+	}
+	//Missions that don't override the knights' speed get their default speed scaled instead
+	if scaled.knightSpeed <= 0.0 {
+		if arch := archetypes["knight"]; arch != nil {
+			scaled.knightSpeed = arch.maxSpeed
+		}
+	}
+	scaled.knightSpeed *= def.knightSpeed
+	pacing := mis.pacing
+	if len(pacing) == 0 {
+		pacing = defaultPacing
+	}
+	scaled.pacing = make([]PacingPoint, len(pacing))
+	for i, p := range pacing {
+		p.waveInterval *= def.waveInterval
+		scaled.pacing[i] = p
+	}
+	return &scaled
+}
+
+//Returns how much love the player loses from a hit that would cost the given amount on normal difficulty
+func (d Difficulty) HurtDamage(base int) int {
+	return max(1, int(math.Round(float64(base)*difficultyDefs[d].hurtDamage)))
+}
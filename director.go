@@ -0,0 +1,218 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+type DirectorPhase int
+
+const (
+	DP_BUILDUP DirectorPhase = iota //Enemies are sent in waves until the intensity peaks
+	DP_PEAK                         //The intensity is sustained for a while without new waves
+	DP_RELAX                        //Quiet period where reinforcements only trickle in from far away
+)
+
+func (phase DirectorPhase) String() string {
+	switch phase {
+	case DP_BUILDUP:
+		return "BUILD"
+	case DP_PEAK:
+		return "PEAK"
+	case DP_RELAX:
+		return "RELAX"
+	}
+	return "?"
+}
+
+const (
+	DIR_INTENSITY_DECAY = 0.08  //Intensity lost per second
+	DIR_DAMAGE_WEIGHT   = 0.025 //Intensity gained per point of love lost
+	DIR_NEARBY_WEIGHT   = 0.06  //Intensity contributed by each enemy close to the player
+	DIR_NEARBY_RADIUS   = 128.0 //Distance from the player within which enemies count as close
+	DIR_GAIN_MEMORY     = 10.0  //Time in seconds over which recent love gains are remembered
+	DIR_GAIN_WEIGHT     = 0.01  //How much further the build up goes per point of recently gained love
+	DIR_PEAK_TIME       = 6.0   //How long the peak phase lasts
+	DIR_RELAX_INTENSITY = 0.2   //Intensity under which the quiet period is allowed to end
+)
+
+//Distance bands from the player in which enemies are placed
+const (
+	DIR_NEAR_BAND_MIN = SCR_WIDTH_H + TILE_SIZE
+	DIR_NEAR_BAND_MAX = SCR_WIDTH
+	DIR_FAR_BAND_MIN  = SCR_WIDTH * 1.5
+	DIR_FAR_BAND_MAP  = 0.75 //On small maps, the far band starts at this fraction of the longest distance on the map instead
+)
+
+//A point on a mission's pacing curve. The director interpolates between points based on how much of the love quota has been collected.
+type PacingPoint struct {
+	progress      float64 //Fraction of the love quota at which this point applies
+	waveInterval  float64 //Time in seconds between waves during the build up
+	waveSize      int     //Number of enemies sent per wave
+	peakIntensity float64 //Intensity at which the build up ends
+	relaxTime     float64 //Minimum length of the quiet period
+}
+
+//Used for missions that don't define their own pacing
+var defaultPacing = []PacingPoint{
+	{progress: 0.0, waveInterval: 4.0, waveSize: 1, peakIntensity: 0.6, relaxTime: 6.0},
+	{progress: 1.0, waveInterval: 3.0, waveSize: 2, peakIntensity: 0.9, relaxTime: 4.0},
+}
+
+//Decides when and where enemies are respawned, based on how much pressure the player is under
+type Director struct {
+	pacing     []PacingPoint
+	phase      DirectorPhase
+	phaseTimer float64
+	waveTimer  float64
+	intensity  float64 //Stress accumulated from taking damage
+	nearby     int     //Number of enemies close to the player as of the last update
+	recentGain float64 //Love gained recently, fading over time
+}
+
+//Creates a director for the mission. Its subscriptions are added to the given scope.
+func NewDirector(mission *Mission, scope *SignalScope) *Director {
+	dir := &Director{
+		pacing: mission.pacing,
+		phase:  DP_BUILDUP,
+	}
+	if len(dir.pacing) == 0 {
+		dir.pacing = defaultPacing
+	}
+	scope.Track(Listen_Signal(dir.OnLoveChanged))
+	return dir
+}
+
+func (dir *Director) OnLoveChanged(ev LoveChanged) {
+	if diff := ev.New - ev.Old; diff < 0 {
+		dir.intensity += float64(-diff) * DIR_DAMAGE_WEIGHT
+	} else {
+		dir.recentGain += float64(diff)
+	}
+}
+
+//Returns the pressure the player is currently under. Around 1.0 is considered a lot.
+func (dir *Director) Intensity() float64 {
+	return dir.intensity + float64(dir.nearby)*DIR_NEARBY_WEIGHT
+}
+
+//Returns the pacing parameters for the current point in the mission
+func (dir *Director) CurrentPacing(game *Game) PacingPoint {
+	progress := float64(game.love) / float64(game.mission.loveQuota)
+	if progress <= dir.pacing[0].progress {
+		return dir.pacing[0]
+	}
+	for i := 1; i < len(dir.pacing); i++ {
+		a, b := dir.pacing[i-1], dir.pacing[i]
+		if progress <= b.progress {
+			t := (progress - a.progress) / (b.progress - a.progress)
+			return PacingPoint{
+				progress:      progress,
+				waveInterval:  a.waveInterval + (b.waveInterval-a.waveInterval)*t,
+				waveSize:      int(math.Round(float64(a.waveSize) + float64(b.waveSize-a.waveSize)*t)),
+				peakIntensity: a.peakIntensity + (b.peakIntensity-a.peakIntensity)*t,
+				relaxTime:     a.relaxTime + (b.relaxTime-a.relaxTime)*t,
+			}
+		}
+	}
+	return dir.pacing[len(dir.pacing)-1]
+}
+
+func (dir *Director) Update(game *Game) {
+	dir.intensity = math.Max(0.0, dir.intensity-DIR_INTENSITY_DECAY*game.deltaTime)
+	dir.recentGain -= dir.recentGain * math.Min(1.0, game.deltaTime/DIR_GAIN_MEMORY)
+
+	dir.nearby = 0
+	for objE := game.objects.Front(); objE != nil; objE = objE.Next() {
+		obj := objE.Value.(*Object)
+		if obj.HasColType(CT_ENEMY) && obj.archetype != "" && obj.pos.Clone().Sub(game.playerObj.pos).Length() < DIR_NEARBY_RADIUS {
+			dir.nearby++
+		}
+	}
+
+	pacing := dir.CurrentPacing(game)
+
+	//Players who fall behind the par time get enemies (and therefore love) sent their way more often
+	behind := float64(game.elapsedTime)/float64(game.mission.parTime) - float64(game.love)/float64(game.mission.loveQuota)
+	interval := pacing.waveInterval * (1.0 - math.Max(-0.5, math.Min(0.5, behind)))
+	//Players who are collecting love quickly are handling the pressure, so the build up is allowed to go further
+	peak := pacing.peakIntensity + math.Min(0.3, dir.recentGain*DIR_GAIN_WEIGHT)
+
+	dir.phaseTimer += game.deltaTime
+	dir.waveTimer += game.deltaTime
+	switch dir.phase {
+	case DP_BUILDUP:
+		if dir.waveTimer > interval {
+			dir.waveTimer = 0.0
+			dir.SpawnWave(game, pacing.waveSize, DIR_NEAR_BAND_MIN, DIR_NEAR_BAND_MAX)
+		}
+		if dir.Intensity() >= peak {
+			dir.ChangePhase(DP_PEAK)
+		}
+	case DP_PEAK:
+		if dir.phaseTimer > DIR_PEAK_TIME {
+			dir.ChangePhase(DP_RELAX)
+		}
+	case DP_RELAX:
+		//Restock the level far away from the player so that the next build up has something to work with
+		if dir.waveTimer > interval*2.0 {
+			dir.waveTimer = 0.0
+			dir.SpawnWave(game, 1, math.Min(DIR_FAR_BAND_MIN, game.level.MaxWrappedDistance()*DIR_FAR_BAND_MAP), math.Inf(1))
+		}
+		if dir.phaseTimer > pacing.relaxTime && dir.Intensity() < DIR_RELAX_INTENSITY {
+			dir.ChangePhase(DP_BUILDUP)
+		}
+	}
+}
+
+func (dir *Director) ChangePhase(phase DirectorPhase) {
+	dir.phase = phase
+	dir.phaseTimer = 0.0
+	dir.waveTimer = 0.0
+}
+
+//Spawns up to count archetypes that are under the mission's caps, between minDist and maxDist away from the player
+func (dir *Director) SpawnWave(game *Game, count int, minDist, maxDist float64) {
+	for i := 0; i < count; i++ {
+		pool := make([]string, 0, len(game.mission.spawns))
+		for _, sc := range game.mission.spawns {
+			if game.census.Count(sc.archetype) < sc.max {
+				pool = append(pool, sc.archetype)
+			}
+		}
+		if len(pool) == 0 {
+			return
+		}
+
+		spawn := game.level.FindSpawnPointInBand(game, minDist, maxDist)
+		if spawn == nil {
+			spawn = game.level.FindOffscreenSpawnPoint(game)
+		}
+		if spawn == nil {
+			return
+		}
+		SpawnArchetype(game, pool[rand.Intn(len(pool))], spawn.centerX, spawn.centerY)
+	}
+}
+
+func (dir *Director) DebugString() string {
+	return fmt.Sprintf("%s %.2f", dir.phase, dir.Intensity())
+}
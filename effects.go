@@ -56,12 +56,28 @@ func init() {
 }
 
 func AddExplosion(game *Game, x, y float64) *Object {
+	return addChainExplosion(game, x, y, &RuneChain{})
+}
+
+//Keeps count of the runes set off by one chain reaction of explosions
+type RuneChain struct {
+	runes int
+}
+
+//Blows up the rune tile as part of the chain reaction
+func (chain *RuneChain) Detonate(game *Game, t *Tile) {
+	chain.runes++
+	Emit_Signal(RuneExploded{Pos: vmath.NewVec(t.centerX, t.centerY), Chain: chain.runes})
+	addChainExplosion(game, t.centerX, t.centerY, chain)
+}
+
+func addChainExplosion(game *Game, x, y float64, chain *RuneChain) *Object {
 	obj := &Object{
-		pos:          vmath.NewVec(x, y),
-		radius:       8.0,
-		colType:      CT_EXPLOSION,
-		sprites:      []*Sprite{sprExplosion[0]},
-		drawPriority: 20,
+		pos:     vmath.NewVec(x, y),
+		radius:  8.0,
+		colType: CT_EXPLOSION,
+		sprites: []*Sprite{sprExplosion[0]},
+		layer:   RL_EFFECTS,
 	}
 	effect := new(Effect)
 	effect.anim = Anim{
@@ -83,7 +99,7 @@ func AddExplosion(game *Game, x, y float64) *Object {
 			for _, t := range tiles {
 				//Make runes spawn more explosions
 				if t.tt == TT_RUNE {
-					AddExplosion(game, t.centerX, t.centerY)
+					chain.Detonate(game, t)
 				}
 				game.level.DestroyTile(t)
 			}
@@ -92,6 +108,7 @@ func AddExplosion(game *Game, x, y float64) *Object {
 	obj.components = []Component{effect}
 	game.AddObject(obj)
 	audio.PlaySoundAttenuated("explode", 256.0, obj.pos, game.camMin, game.camMax)
+	MakeNoise(obj.pos, NOISE_EXPLOSION, obj)
 	return obj
 }
 
@@ -103,11 +120,11 @@ func init() {
 
 func AddPoof(game *Game, x, y float64) *Object {
 	obj := &Object{
-		pos:          vmath.NewVec(x, y),
-		radius:       0.0,
-		colType:      CT_NONE,
-		sprites:      []*Sprite{sprPoof[0]},
-		drawPriority: 5,
+		pos:     vmath.NewVec(x, y),
+		radius:  0.0,
+		colType: CT_NONE,
+		sprites: []*Sprite{sprPoof[0]},
+		layer:   RL_EFFECTS,
 	}
 	effect := new(Effect)
 	effect.anim = Anim{
@@ -134,11 +151,11 @@ func AddStarBurst(game *Game, x, y float64) {
 	angle := rand.Float64() * math.Pi * 2.0
 	for a := 0.0; a < math.Pi*2.0; a += (rand.Float64() * math.Pi / 4.0) + math.Pi/8.0 {
 		obj := &Object{
-			pos:          vmath.NewVec(x, y),
-			radius:       0.0,
-			colType:      CT_NONE,
-			sprites:      []*Sprite{sprStars[0]},
-			drawPriority: 25,
+			pos:     vmath.NewVec(x, y),
+			radius:  0.0,
+			colType: CT_NONE,
+			sprites: []*Sprite{sprStars[0]},
+			layer:   RL_EFFECTS,
 		}
 		effect := new(Effect)
 		effect.anim = Anim{
@@ -0,0 +1,211 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/thetophatdemon/feta-feles-rebirth/audio"
+)
+
+const (
+	MISSION_ENDLESS       = -1   //Mission number of endless mode's stages
+	ENDLESS_FIRST_MISSION = 1    //The first stage is based on this mission. The tutorial is skipped.
+	ENDLESS_CAP_GROWTH    = 0.15 //Added to the multiplier of the spawn caps for each stage after the missions run out
+	ENDLESS_QUOTA_GROWTH  = 10   //Added to the love quota for each stage after the missions run out
+	ENDLESS_MAX_QUOTA     = 200
+	ENDLESS_MAP_GROWTH    = 8 //Tiles added to each side of the map for each stage after the missions run out
+	ENDLESS_MAX_MAP_SIZE  = 128
+	ENDLESS_STAGE_POINTS  = 500 //Times the stage number, for clearing a stage
+	ENDLESS_SCORES_FILE   = "endless.json"
+	ENDLESS_SCORES_KEPT   = 10
+)
+
+//Progress through endless mode. It carries over from one stage to the next.
+type EndlessRun struct {
+	stage  int  //Starts at 1
+	score  int  //Total of the scores of the stages played before the current one
+	failed bool //Set when the clock runs out
+}
+
+//Makes the mission for a stage of endless mode. The stages go through the missions in order, and then keep getting harder.
+func EndlessMission(stage int) *Mission {
+	idx := min(ENDLESS_FIRST_MISSION+stage-1, len(missions)-1)
+	over := float64(max(0, ENDLESS_FIRST_MISSION+stage-1-idx)) //Stages past the last mission
+	mis := missions[idx]
+	mis.goodEndFlag = false
+	mis.spawns = make([]SpawnCap, len(missions[idx].spawns))
+	for i, sc := range missions[idx].spawns {
+		mis.spawns[i] = SpawnCap{archetype: sc.archetype, max: int(math.Round(float64(sc.max) * (1.0 + over*ENDLESS_CAP_GROWTH)))}
+	}
+	mis.loveQuota = min(mis.loveQuota+int(over)*ENDLESS_QUOTA_GROWTH, max(mis.loveQuota, ENDLESS_MAX_QUOTA))
+	mis.mapWidth = min(mis.mapWidth+int(over)*ENDLESS_MAP_GROWTH, max(mis.mapWidth, ENDLESS_MAX_MAP_SIZE))
+	mis.mapHeight = min(mis.mapHeight+int(over)*ENDLESS_MAP_GROWTH, max(mis.mapHeight, ENDLESS_MAX_MAP_SIZE))
+	mis.catHealth += int(over)
+	//More love to collect means more time is needed to collect it
+	mis.parTime = mis.parTime * mis.loveQuota / missions[idx].loveQuota
+	return &mis
+}
+
+//Starts a new run of endless mode from the first stage
+func NewEndlessRun() *EndlessRun {
+	__totalGameTime = 0.0
+	__runStats = NewStats()
+	StartTelemetryRun()
+	return &EndlessRun{stage: 1}
+}
+
+//Starts the run's current stage
+func NewEndlessGame(run *EndlessRun) *Game {
+	game := newGame(MISSION_ENDLESS, EndlessMission(run.stage), run)
+	if __telemetry != nil {
+		__telemetry.RecordMission(game, game.Signals())
+	}
+	game.Track(Listen_Signal(func(ev GameStarted) {
+		game.hud.DisplayMessage(fmt.Sprintf("STAGE %d", run.stage), 2.0)
+	}))
+	game.Track(Listen_Signal(func(ev CatDied) {
+		if run.failed {
+			return //Too late
+		}
+		game.score.points[SK_STAGE] += ENDLESS_STAGE_POINTS * run.stage
+		Emit_Signal(StageEnded{Stage: run.stage, Cleared: true, Pos: ev.Pos})
+		run.stage++
+	}))
+	return game
+}
+
+//Ends the run when the time for the stage runs out
+func (run *EndlessRun) Update(game *Game) {
+	if game.fade == FM_NO_FADE && !run.failed && game.elapsedTime >= float64(game.mission.parTime) {
+		run.failed = true
+		game.fade = FM_FADE_OUT
+		audio.PlaySound("descend")
+		Emit_Signal(StageEnded{Stage: run.stage, Cleared: false, Pos: game.playerObj.pos.Clone()})
+	}
+}
+
+//Returns what comes after the current stage: either the next one, or the results when the run is over
+func (run *EndlessRun) Next() AppState {
+	if run.failed {
+		return &EndlessResults{run: run}
+	}
+	return NewEndlessGame(run)
+}
+
+//An entry in the endless mode high score table
+type EndlessScore struct {
+	Score      int       `json:"score"`
+	Stage      int       `json:"stage"` //Stage on which the run ended
+	Difficulty string    `json:"difficulty"`
+	Date       time.Time `json:"date"`
+}
+
+//Contents of the endless mode save file
+type EndlessSave struct {
+	Scores []EndlessScore `json:"scores"` //Best first
+}
+
+func loadEndlessSave() *EndlessSave {
+	save := &EndlessSave{}
+	if err := LoadSaveFile(ENDLESS_SCORES_FILE, save); err != nil {
+		log.Println("Cannot load endless mode scores: ", err)
+	}
+	return save
+}
+
+//Adds the run to the high score table if it's good enough. Returns its place in the table, or -1 if it didn't make it.
+func (save *EndlessSave) Record(run *EndlessRun, diff Difficulty) int {
+	entry := EndlessScore{Score: run.score, Stage: run.stage, Difficulty: diff.String(), Date: time.Now()}
+	//Ties go to the older score
+	rank := sort.Search(len(save.Scores), func(i int) bool { return save.Scores[i].Score < entry.Score })
+	if rank >= ENDLESS_SCORES_KEPT || __botPlaying {
+		return -1
+	}
+	save.Scores = append(save.Scores, EndlessScore{})
+	copy(save.Scores[rank+1:], save.Scores[rank:])
+	save.Scores[rank] = entry
+	if len(save.Scores) > ENDLESS_SCORES_KEPT {
+		save.Scores = save.Scores[:ENDLESS_SCORES_KEPT]
+	}
+	if err := WriteSaveFile(ENDLESS_SCORES_FILE, save); err != nil {
+		log.Println("Cannot save endless mode scores: ", err)
+	}
+	return rank
+}
+
+//Shown when a run of endless mode is over. Lists the high scores, with the run's place in them.
+type EndlessResults struct {
+	SignalScope
+	run    *EndlessRun
+	uiRoot *UINode
+}
+
+func (er *EndlessResults) Enter() {
+	save := loadEndlessSave()
+	rank := save.Record(er.run, __difficulty)
+	audio.PlayMusic("")
+
+	er.uiRoot = EmptyUINode()
+	panel := CreateUIBox(image.Rect(136, 40, 160, 48), image.Rect(16, 8, SCR_WIDTH-16, SCR_HEIGHT-8), true)
+	er.uiRoot.AddChild(&panel.UINode)
+
+	titleBox := CreateUIBox(image.Rect(112, 40, 136, 48), image.Rect(0, 0, 96, 16), true) //Header
+	titleBox.AddChild(&GenerateText("GAME OVER", image.Rect(8, 4, 2048, 2048)).UINode)
+	panel.AddChild(&titleBox.UINode)
+
+	lineRect := image.Rect(0, 0, SCR_WIDTH-32-16, 8)
+	summary := fmt.Sprintf("STAGE %d  SCORE %d", er.run.stage, er.run.score)
+	panel.AddChild(&GenerateText(summary, lineRect).UINode)
+	panel.AddChild(&GenerateText("", lineRect).UINode)
+	panel.AddChild(&GenerateText(fmt.Sprintf("   %-8s%-7s%s", "SCORE", "STAGE", "DIFFICULTY"), lineRect).UINode)
+	for i, entry := range save.Scores {
+		marker := ""
+		if i == rank {
+			marker = " <"
+		}
+		line := fmt.Sprintf("%2d %-8d%-7d%s%s", i+1, entry.Score, entry.Stage, entry.Difficulty, marker)
+		panel.AddChild(&GenerateText(line, lineRect).UINode)
+	}
+	panel.AddChild(&GenerateText("", lineRect).UINode)
+	panel.AddChild(&GenerateText("CLICK OR SPACE TO RETURN", lineRect).UINode)
+
+	panel.ArrangeChildren(image.Rect(4, 4, 4, 4), true)
+}
+
+func (er *EndlessResults) Leave() {
+	er.uiRoot.Unlink()
+}
+
+func (er *EndlessResults) Update(deltaTime float64) {
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		audio.PlaySound("menu")
+		ChangeAppState(new(TitleScreen))
+	}
+}
+
+func (er *EndlessResults) Draw(screen *ebiten.Image) {
+	er.uiRoot.Draw(screen, nil)
+}
@@ -64,7 +64,17 @@ func MakeFeles(ft FaceType, bt BodyType, pos *vmath.Vec2f) *Object {
 		FACE_OFS_Y = -32.0
 	}
 
-	sprites := make([]*Sprite, 0, 8)
+	feles := &Object{
+		pos:        pos.Clone(),
+		colType:    CT_NONE,
+		radius:     0.0,
+		layer:      RL_OVERLAY,
+		components: []Component{},
+	}
+	//Each part is a child placed relative to the face, so parts added later are drawn on top
+	addPart := func(sprites ...*Sprite) {
+		feles.AttachChild(&Object{sprites: sprites}, FACE_OFS_X, FACE_OFS_Y)
+	}
 
 	normalTailRect := image.Rect(176, 80, 192, 96)
 	doubleTailRect := image.Rect(176, 96, 208, 112)
@@ -74,19 +84,14 @@ func MakeFeles(ft FaceType, bt BodyType, pos *vmath.Vec2f) *Object {
 	//===============================
 	switch bt {
 	case BODY_CAT:
-		sprites = append(sprites, NewSprite(normalTailRect,
-			vmath.NewVec(FACE_OFS_X+4.0, FACE_OFS_Y+32.0), false, false, 0))
+		addPart(NewSprite(normalTailRect, vmath.NewVec(4.0, 32.0), false, false, 0))
 	case BODY_HUMAN, BODY_ANGEL:
-		sprites = append(sprites, NewSprite(normalTailRect,
-			vmath.NewVec(FACE_OFS_X+4.0, FACE_OFS_Y+52.0), false, false, 0))
+		addPart(NewSprite(normalTailRect, vmath.NewVec(4.0, 52.0), false, false, 0))
 	case BODY_ANGEL2:
-		sprites = append(sprites, NewSprite(doubleTailRect,
-			vmath.NewVec(FACE_OFS_X-16.0, FACE_OFS_Y+52.0), false, false, 0))
+		addPart(NewSprite(doubleTailRect, vmath.NewVec(-16.0, 52.0), false, false, 0))
 	case BODY_CORRUPTED:
-		sprites = append(sprites, NewSprite(quadTailRect,
-			vmath.NewVec(FACE_OFS_X-16.0, FACE_OFS_Y+42.0), false, false, 0))
-		sprites = append(sprites, NewSprite(quadTailRect,
-			vmath.NewVec(FACE_OFS_X+32.0, FACE_OFS_Y+42.0), true, false, 0))
+		addPart(NewSprite(quadTailRect, vmath.NewVec(-16.0, 42.0), false, false, 0),
+			NewSprite(quadTailRect, vmath.NewVec(32.0, 42.0), true, false, 0))
 	}
 
 	//==================================
@@ -96,15 +101,11 @@ func MakeFeles(ft FaceType, bt BodyType, pos *vmath.Vec2f) *Object {
 	corruptWingRect := image.Rect(176, 160, 208, 176)
 	switch bt {
 	case BODY_ANGEL, BODY_ANGEL2:
-		sprites = append(sprites, NewSprite(angelWingRect,
-			vmath.NewVec(FACE_OFS_X-16.0, FACE_OFS_Y+30.0), false, false, 0))
-		sprites = append(sprites, NewSprite(angelWingRect,
-			vmath.NewVec(FACE_OFS_X+32.0, FACE_OFS_Y+30.0), true, false, 0))
+		addPart(NewSprite(angelWingRect, vmath.NewVec(-16.0, 30.0), false, false, 0),
+			NewSprite(angelWingRect, vmath.NewVec(32.0, 30.0), true, false, 0))
 	case BODY_CORRUPTED:
-		sprites = append(sprites, NewSprite(corruptWingRect,
-			vmath.NewVec(FACE_OFS_X-16.0, FACE_OFS_Y+30.0), false, false, 0))
-		sprites = append(sprites, NewSprite(corruptWingRect,
-			vmath.NewVec(FACE_OFS_X+32.0, FACE_OFS_Y+30.0), true, false, 0))
+		addPart(NewSprite(corruptWingRect, vmath.NewVec(-16.0, 30.0), false, false, 0),
+			NewSprite(corruptWingRect, vmath.NewVec(32.0, 30.0), true, false, 0))
 	}
 
 	//=========================
@@ -112,21 +113,22 @@ func MakeFeles(ft FaceType, bt BodyType, pos *vmath.Vec2f) *Object {
 	//=========================
 	switch bt {
 	case BODY_CAT:
-		sprites = append(sprites, NewSprite(image.Rect(192, 64, 208, 96), vmath.NewVec(FACE_OFS_X+8.0, FACE_OFS_Y+27.0), false, false, 0))     //Left half
-		sprites = append(sprites, NewSprite(image.Rect(192, 64, 208, 96), vmath.NewVec(FACE_OFS_X+8.0+16.0, FACE_OFS_Y+27.0), true, false, 0)) //Right Half
+		addPart(NewSprite(image.Rect(192, 64, 208, 96), vmath.NewVec(8.0, 27.0), false, false, 0), //Left half
+			NewSprite(image.Rect(192, 64, 208, 96), vmath.NewVec(24.0, 27.0), true, false, 0)) //Right Half
 	case BODY_HUMAN, BODY_ANGEL:
-		sprites = append(sprites, NewSprite(image.Rect(192, 0, 208, 64), vmath.NewVec(FACE_OFS_X+8.0, FACE_OFS_Y+28.0), false, false, 0))     //Left half
-		sprites = append(sprites, NewSprite(image.Rect(192, 0, 208, 64), vmath.NewVec(FACE_OFS_X+8.0+16.0, FACE_OFS_Y+28.0), true, false, 0)) //Right Half
+		addPart(NewSprite(image.Rect(192, 0, 208, 64), vmath.NewVec(8.0, 28.0), false, false, 0), //Left half
+			NewSprite(image.Rect(192, 0, 208, 64), vmath.NewVec(24.0, 28.0), true, false, 0)) //Right Half
 	case BODY_ANGEL2:
-		sprites = append(sprites, NewSprite(image.Rect(176, 0, 192, 64), vmath.NewVec(FACE_OFS_X+8.0, FACE_OFS_Y+28.0), false, false, 0))     //Left half
-		sprites = append(sprites, NewSprite(image.Rect(176, 0, 192, 64), vmath.NewVec(FACE_OFS_X+8.0+16.0, FACE_OFS_Y+28.0), true, false, 0)) //Right Half
+		addPart(NewSprite(image.Rect(176, 0, 192, 64), vmath.NewVec(8.0, 28.0), false, false, 0), //Left half
+			NewSprite(image.Rect(176, 0, 192, 64), vmath.NewVec(24.0, 28.0), true, false, 0)) //Right Half
 	case BODY_CORRUPTED:
-		sprites = append(sprites, NewSprite(image.Rect(160, 0, 176, 64), vmath.NewVec(FACE_OFS_X+8.0, FACE_OFS_Y+28.0), false, false, 0))     //Left half
-		sprites = append(sprites, NewSprite(image.Rect(160, 0, 176, 64), vmath.NewVec(FACE_OFS_X+8.0+16.0, FACE_OFS_Y+28.0), true, false, 0)) //Right Half
+		addPart(NewSprite(image.Rect(160, 0, 176, 64), vmath.NewVec(8.0, 28.0), false, false, 0), //Left half
+			NewSprite(image.Rect(160, 0, 176, 64), vmath.NewVec(24.0, 28.0), true, false, 0)) //Right Half
 	case BODY_MELTED:
-		sprites = append(sprites, NewSprite(image.Rect(128, 208, 208, 256), vmath.NewVec(FACE_OFS_X-17.0, FACE_OFS_Y+28.0), false, false, 0))
+		addPart(NewSprite(image.Rect(128, 208, 208, 256), vmath.NewVec(-17.0, 28.0), false, false, 0))
 	case BODY_HORROR:
-		sprites = append(sprites, NewSprite(image.Rect(0, 160, 128, 256), vmath.NewVec(-60.0, -48.0), false, false, 0))
+		//This one is centered on Feles instead of the face
+		feles.AttachChild(&Object{sprites: []*Sprite{NewSprite(image.Rect(0, 160, 128, 256), vmath.NewVec(-60.0, -48.0), false, false, 0)}}, 0.0, 0.0)
 	}
 
 	//===================================
@@ -154,15 +156,8 @@ func MakeFeles(ft FaceType, bt BodyType, pos *vmath.Vec2f) *Object {
 		case FACE_MELTED:
 			faceRect = image.Rect(208, 224, 256, 256)
 		}
-		sprites = append(sprites, NewSprite(faceRect, vmath.NewVec(FACE_OFS_X, FACE_OFS_Y), false, false, 0))
+		addPart(NewSprite(faceRect, vmath.ZeroVec(), false, false, 0))
 	}
 
-	return &Object{
-		pos:          pos.Clone(),
-		colType:      CT_NONE,
-		radius:       0.0,
-		drawPriority: 666,
-		components:   []Component{},
-		sprites:      sprites,
-	}
+	return feles
 }
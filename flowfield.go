@@ -0,0 +1,244 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+const (
+	FLOW_BUDGET     = 1024 //Number of tiles the field may visit per update while it is being rebuilt
+	FLOW_FLEE_SCALE = -1.2 //Multiplies the distances to the goal to seed the flee field. Bigger values make fleeing things take longer detours to get further away.
+)
+
+//A map of the distance from every tile to a goal tile, which many things can follow at once without finding their own paths.
+//It is rebuilt over several updates whenever the goal moves to another tile or the level changes. The old distances are used until then.
+//Tiles are indexed as gridY*cols+gridX.
+type FlowField struct {
+	level        *Level
+	wrap         bool      //If set, distances are measured across the edges of the map
+	dist         []float64 //Distance to the goal by tile index
+	flee         []float64 //Lower values are further from the goal, accounting for dead ends
+	goalX, goalY int
+	goalPos      *vmath.Vec2f
+	version      int //Level's navVersion when the field was last built
+
+	//Rebuild in progress
+	building     bool
+	buildingFlee bool //True once the goal distances are done and the flee distances are being found
+	nextDist     []float64
+	nextFlee     []float64
+	open         flowHeap
+	nextX, nextY int
+	nextVersion  int
+}
+
+type flowEntry struct {
+	tile int
+	dist float64
+}
+
+type flowHeap []flowEntry
+
+func (h flowHeap) Len() int            { return len(h) }
+func (h flowHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h flowHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *flowHeap) Push(x interface{}) { *h = append(*h, x.(flowEntry)) }
+func (h *flowHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+func NewFlowField(level *Level, wrap bool) *FlowField {
+	ff := &FlowField{
+		level:   level,
+		wrap:    wrap,
+		goalX:   -1,
+		goalY:   -1,
+		goalPos: vmath.ZeroVec(),
+	}
+	return ff
+}
+
+//Moves the goal and continues any rebuilding that needs to be done
+func (ff *FlowField) Update(goal *vmath.Vec2f) {
+	ff.goalPos = goal.Clone()
+	goalTile := ff.level.GetTile(int(goal.X/TILE_SIZE), int(goal.Y/TILE_SIZE), true)
+	gx, gy := goalTile.gridX, goalTile.gridY
+	//Rebuilds aren't restarted once they're going, or a fast moving goal would keep any of them from finishing
+	outdated := gx != ff.goalX || gy != ff.goalY || ff.version != ff.level.navVersion
+	if outdated && !ff.building && !goalTile.IsSolid() {
+		ff.startBuild(gx, gy)
+	}
+	if ff.building {
+		ff.build(FLOW_BUDGET)
+	}
+	//Build the whole thing at once the first time so that there's something to follow
+	if ff.dist == nil && ff.building {
+		ff.build(math.MaxInt32)
+	}
+}
+
+func (ff *FlowField) startBuild(gx, gy int) {
+	size := ff.level.rows * ff.level.cols
+	if ff.nextDist == nil {
+		ff.nextDist = make([]float64, size)
+		ff.nextFlee = make([]float64, size)
+	}
+	for i := range ff.nextDist {
+		ff.nextDist[i] = math.Inf(1)
+	}
+	ff.nextX, ff.nextY = gx, gy
+	ff.nextVersion = ff.level.navVersion
+	ff.building = true
+	ff.buildingFlee = false
+	goal := gy*ff.level.cols + gx
+	ff.nextDist[goal] = 0.0
+	ff.open = flowHeap{{goal, 0.0}}
+}
+
+//Visits up to budget tiles of the rebuild. The new distances replace the old ones once they're done.
+func (ff *FlowField) build(budget int) {
+	dist := ff.nextDist
+	if ff.buildingFlee {
+		dist = ff.nextFlee
+	}
+	for n := 0; n < budget; n++ {
+		if ff.open.Len() == 0 {
+			if !ff.buildingFlee {
+				//Seed the flee field with the scaled distances and let it settle into the lowest nearby values
+				ff.buildingFlee = true
+				ff.open = ff.open[:0]
+				for i, d := range ff.nextDist {
+					if math.IsInf(d, 1) {
+						ff.nextFlee[i] = d
+					} else {
+						ff.nextFlee[i] = d * FLOW_FLEE_SCALE
+						ff.open = append(ff.open, flowEntry{i, ff.nextFlee[i]})
+					}
+				}
+				heap.Init(&ff.open)
+				dist = ff.nextFlee
+				continue
+			}
+			ff.finishBuild()
+			return
+		}
+		e := heap.Pop(&ff.open).(flowEntry)
+		if e.dist > dist[e.tile] {
+			continue //Already reached by a shorter route
+		}
+		x, y := e.tile%ff.level.cols, e.tile/ff.level.cols
+		for _, dir := range pathDirs {
+			nx, ny, ok := ff.step(x, y, dir)
+			if !ok {
+				continue
+			}
+			cost := 1.0
+			if dir[0] != 0 && dir[1] != 0 {
+				cost = math.Sqrt2
+			}
+			next := ny*ff.level.cols + nx
+			if d := e.dist + cost; d < dist[next] {
+				dist[next] = d
+				heap.Push(&ff.open, flowEntry{next, d})
+			}
+		}
+	}
+}
+
+func (ff *FlowField) finishBuild() {
+	ff.dist, ff.nextDist = ff.nextDist, ff.dist
+	ff.flee, ff.nextFlee = ff.nextFlee, ff.flee
+	ff.goalX, ff.goalY = ff.nextX, ff.nextY
+	ff.version = ff.nextVersion
+	ff.building = false
+	ff.open = ff.open[:0]
+}
+
+//Returns the coordinates of the neighboring tile in the direction, and whether it can be moved to from x, y
+func (ff *FlowField) step(x, y int, dir [2]int) (int, int, bool) {
+	nx, ny := x+dir[0], y+dir[1]
+	if ff.wrap {
+		nx, ny = ff.level.WrapGridCoords(nx, ny)
+	}
+	if !ff.level.walkable(nx, ny, ff.wrap) {
+		return nx, ny, false
+	}
+	if dir[0] != 0 && dir[1] != 0 {
+		//Don't cut through the corners of walls
+		cx := float64(x)*TILE_SIZE + TILE_SIZE_H + float64(dir[0])*TILE_SIZE_H
+		cy := float64(y)*TILE_SIZE + TILE_SIZE_H + float64(dir[1])*TILE_SIZE_H
+		if !ff.level.cornerPassable(x+dir[0], y, cx, cy, ff.wrap) || !ff.level.cornerPassable(x, y+dir[1], cx, cy, ff.wrap) {
+			return nx, ny, false
+		}
+	}
+	return nx, ny, true
+}
+
+//Returns the distance in tiles from the position to the goal, or infinity if it can't be reached
+func (ff *FlowField) Distance(pos *vmath.Vec2f) float64 {
+	x, y := int(pos.X/TILE_SIZE), int(pos.Y/TILE_SIZE)
+	if ff.dist == nil || x < 0 || y < 0 || x >= ff.level.cols || y >= ff.level.rows {
+		return math.Inf(1)
+	}
+	return ff.dist[y*ff.level.cols+x]
+}
+
+//Returns the direction to move in from the position to get closer to the goal. Returns a zero vector if there is no way.
+func (ff *FlowField) Direction(pos *vmath.Vec2f) *vmath.Vec2f {
+	x, y := int(pos.X/TILE_SIZE), int(pos.Y/TILE_SIZE)
+	if x == ff.goalX && y == ff.goalY {
+		return ff.level.Displacement(pos, ff.goalPos, ff.wrap).Normalize()
+	}
+	return ff.descend(ff.dist, pos)
+}
+
+//Returns the direction to move in from the position to get away from the goal without getting cornered. Returns a zero vector if there is no way.
+func (ff *FlowField) FleeDirection(pos *vmath.Vec2f) *vmath.Vec2f {
+	return ff.descend(ff.flee, pos)
+}
+
+//Returns the direction toward the neighboring tile with the lowest value
+func (ff *FlowField) descend(values []float64, pos *vmath.Vec2f) *vmath.Vec2f {
+	x, y := int(pos.X/TILE_SIZE), int(pos.Y/TILE_SIZE)
+	if values == nil || x < 0 || y < 0 || x >= ff.level.cols || y >= ff.level.rows {
+		return vmath.ZeroVec()
+	}
+	best := values[y*ff.level.cols+x]
+	var bestTile *Tile
+	for _, dir := range pathDirs {
+		nx, ny, ok := ff.step(x, y, dir)
+		if !ok {
+			continue
+		}
+		if v := values[ny*ff.level.cols+nx]; v < best {
+			best = v
+			bestTile = &ff.level.tiles[ny][nx]
+		}
+	}
+	if bestTile == nil {
+		return vmath.ZeroVec()
+	}
+	return ff.level.Displacement(pos, vmath.NewVec(bestTile.centerX, bestTile.centerY), ff.wrap).Normalize()
+}
@@ -0,0 +1,161 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+type AIState int
+
+const (
+	AI_IDLE   AIState = iota //Hasn't noticed the player
+	AI_WANDER                //Moving around aimlessly
+	AI_ALERT                 //Has noticed something and is getting ready to act
+	AI_CHASE                 //Going after the player
+	AI_ATTACK                //In the middle of an attack
+	AI_SEARCH                //Lost track of the player and is looking for them
+	AI_FLEE                  //Running away from the player
+	AI_DYING                 //Playing the death animation
+	AI_STATE_COUNT
+)
+
+var aiStateNames = [AI_STATE_COUNT]string{"IDLE", "WANDER", "ALERT", "CHASE", "ATTACK", "SEARCH", "FLEE", "DYING"}
+
+func (s AIState) String() string {
+	if s < 0 || s >= AI_STATE_COUNT {
+		return fmt.Sprintf("STATE %d", int(s))
+	}
+	return aiStateNames[s]
+}
+
+//Behavior for one state of a state machine. Any of the hooks can be nil.
+type StateDef struct {
+	Enter   func(game *Game, obj *Object)
+	Update  func(game *Game, obj *Object)
+	Exit    func(game *Game, obj *Object)
+	Timeout float64 //Seconds spent in the state before changing to Next. Zero means no limit. Enter can override it with SetTimeout.
+	Next    AIState
+}
+
+//Drives a mob's behavior by switching between states
+type StateMachine struct {
+	states  [AI_STATE_COUNT]*StateDef
+	current AIState
+	timer   float64 //Time in seconds since the current state was entered
+	timeout float64
+	started bool //False until the first state has been entered
+}
+
+func NewStateMachine(initial AIState) *StateMachine {
+	return &StateMachine{current: initial}
+}
+
+//Sets the behavior of a state. Returns the machine so that definitions can be chained.
+func (sm *StateMachine) Define(state AIState, def StateDef) *StateMachine {
+	sm.states[state] = &def
+	return sm
+}
+
+func (sm *StateMachine) State() AIState {
+	return sm.current
+}
+
+//Returns the time in seconds since the current state was entered
+func (sm *StateMachine) Time() float64 {
+	return sm.timer
+}
+
+//Changes how long the machine stays in the current state, counting from when it was entered
+func (sm *StateMachine) SetTimeout(seconds float64) {
+	sm.timeout = seconds
+}
+
+//Returns true if the current state's time is up. The machine moves on right after the state's Update hook.
+func (sm *StateMachine) Expired() bool {
+	return sm.timeout > 0.0 && sm.timer >= sm.timeout
+}
+
+//Skips forward in the current state. Useful for keeping groups of mobs from acting in sync.
+func (sm *StateMachine) Advance(seconds float64) {
+	sm.timer += seconds
+}
+
+//Leaves the current state and enters the new one, even if it's the same state
+func (sm *StateMachine) Change(game *Game, obj *Object, state AIState) {
+	if sm.started {
+		if def := sm.states[sm.current]; def != nil && def.Exit != nil {
+			def.Exit(game, obj)
+		}
+	}
+	sm.started = true
+	sm.current = state
+	sm.timer = 0.0
+	sm.timeout = 0.0
+	if def := sm.states[state]; def != nil {
+		sm.timeout = def.Timeout
+		if def.Enter != nil {
+			def.Enter(game, obj)
+		}
+	}
+}
+
+func (sm *StateMachine) Update(game *Game, obj *Object) {
+	if !sm.started {
+		sm.Change(game, obj, sm.current)
+	}
+	sm.timer += game.deltaTime
+	state := sm.current
+	def := sm.states[state]
+	if def == nil {
+		return
+	}
+	if def.Update != nil {
+		def.Update(game, obj)
+	}
+	//Only time out if the update didn't already change the state
+	if sm.current == state && sm.Expired() {
+		sm.Change(game, obj, def.Next)
+	}
+}
+
+//Implemented by components whose behavior is run by a state machine, so the debug overlay can show what state they're in
+type StateHolder interface {
+	StateMachine() *StateMachine
+}
+
+//Prints the AI state above each object that has one
+func DrawAIStates(game *Game, screen *ebiten.Image) {
+	for objE := game.objects.Front(); objE != nil; objE = objE.Next() {
+		obj := objE.Value.(*Object)
+		if !game.SquareOnScreen(obj.pos.X, obj.pos.Y, obj.radius) {
+			continue
+		}
+		for _, c := range obj.components {
+			if sh, ok := c.(StateHolder); ok && sh.StateMachine() != nil {
+				x := int(obj.pos.X - game.camMin.X)
+				y := int(obj.pos.Y-game.camMin.Y-obj.radius) - 16
+				ebitenutil.DebugPrintAt(screen, sh.StateMachine().State().String(), x-12, y)
+				break
+			}
+		}
+	}
+}
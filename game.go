@@ -34,7 +34,10 @@ import (
 )
 
 type Game struct {
+	SignalScope
 	objects                *list.List
+	census                 *Census
+	renderQueue            *RenderQueue
 	level                  *Level
 	deltaTime              float64
 	lastTime               time.Time
@@ -44,7 +47,7 @@ type Game struct {
 	missionNumber          int
 	playerObj              *Object
 	love                   int
-	respawnTimer           float64
+	director               *Director
 	fade                   FadeMode
 	fadeTimer              float64
 	fadeStage              int
@@ -56,6 +59,18 @@ type Game struct {
 	elapsedTime            float64
 	pause                  bool
 	tutorialStep           int
+	stats                  *Stats     //Statistics for this mission only
+	seed                   int64      //Random seed used to generate the level
+	playerField            *FlowField //Leads monsters to the player
+	catField               *FlowField //Same as above, but measured across the edges of the map. Used by the cat to run away.
+	perception             *Perception
+	flock                  *Flock
+	boss                   *Demon //Only set for the fight with the demon
+	bossWon                bool
+	difficulty             Difficulty
+	endless                *EndlessRun //Only set in endless mode
+	score                  *Score
+	completed              bool //Set when the cat or the demon is killed
 }
 
 type FadeMode int
@@ -69,74 +84,110 @@ const (
 
 var __totalGameTime float64
 
+//Starts the mission with the given number. The number after the last mission is the fight with the demon.
 func NewGame(mission int) *Game {
-	if mission < 0 || mission >= len(missions) {
+	if mission < 0 || mission > len(missions) {
 		log.Println("Invalid mission number!")
 		mission = int(math.Max(0, math.Min(float64(len(missions)-1), float64(mission))))
 	}
+	mis := &bossMission
+	if mission < len(missions) {
+		mis = &missions[mission]
+	}
 	if mission == 0 {
 		__totalGameTime = 0.0
+		__runStats = NewStats()
+	}
+	game := newGame(mission, mis, nil)
+	if mission == 0 || __telemetry == nil {
+		StartTelemetryRun()
+	}
+	if __telemetry != nil {
+		__telemetry.RecordMission(game, game.Signals())
 	}
+	return game
+}
+
+//Sets up a game for the mission. The number is MISSION_ENDLESS for the stages of endless mode, which pass in their run.
+func newGame(mission int, mis *Mission, run *EndlessRun) *Game {
+	//The game gets its own copy of the mission, adjusted for the difficulty
+	mis = __difficulty.Scale(mis)
 	game := &Game{
 		objects:       list.New(),
+		census:        NewCensus(),
+		renderQueue:   NewRenderQueue(),
 		lastTime:      time.Now(),
 		camPos:        vmath.ZeroVec(),
 		camMin:        vmath.ZeroVec(),
 		camMax:        vmath.ZeroVec(),
-		mission:       &missions[mission],
+		mission:       mis,
 		missionNumber: mission,
+		difficulty:    __difficulty,
+		endless:       run,
 		fade:          FM_FADE_IN,
 		strobeSpeed:   6.0,
 		strobeTimer:   0.0,
 		strobeForward: true,
-		bgColor:       missions[mission].bgColor1,
-		hud:           CreateGameHUD(),
+		bgColor:       mis.bgColor1,
 		tutorialStep:  0,
+		stats:         NewStats(),
+		score:         &Score{},
 	}
+	TrackStats(game.Signals(), game.stats, __runStats)
+	WatchAchievements(game, game.Signals())
+	WatchPowerUpDrops(game, game.Signals())
+	WatchScore(game, game.Signals())
 
+	game.hud = CreateGameHUD(game.Signals())
+	game.director = NewDirector(game.mission, game.Signals())
 	game.renderTarget = ebiten.NewImage(SCR_WIDTH, SCR_HEIGHT)
-	Emit_Signal(SIGNAL_GAME_INIT, game, nil)
-	game.level = GenerateLevel(missions[mission].mapWidth, missions[mission].mapHeight, mission <= 1)
+	Emit_Signal(GameInit{Game: game, Mission: mission})
+	//Keep the seed so that the level can be reproduced from it
+	game.seed = rand.Int63()
+	game.level = GenerateLevel(mis.mapWidth, mis.mapHeight, mission >= 0 && mission <= 1, game.seed)
 
 	//Spawn entities
 	playerSpawn := game.level.FindCenterSpawnPoint(game)
 	game.playerObj = AddPlayer(game, playerSpawn.centerX, playerSpawn.centerY)
+	game.playerField = NewFlowField(game.level, false)
+	game.playerField.Update(game.playerObj.pos)
+	game.catField = NewFlowField(game.level, true)
+	game.catField.Update(game.playerObj.pos)
+	game.perception = NewPerception()
+	game.flock = NewFlock()
+	game.Track(Listen_Signal(func(ev NoiseMade) {
+		game.perception.Hear(game, ev)
+	}))
 
 	game.CenterCameraOn(game.playerObj, true)
 
-	for i := 0; i < missions[mission].maxKnights; i++ {
-		spawn := game.level.FindOffscreenSpawnPoint(game)
-		AddKnight(game, spawn.centerX, spawn.centerY)
-	}
-	for i := 0; i < missions[mission].maxBlarghs; i++ {
-		spawn := game.level.FindOffscreenSpawnPoint(game)
-		AddBlargh(game, spawn.centerX, spawn.centerY)
-	}
-	for i := 0; i < missions[mission].maxGopniks; i++ {
-		spawn := game.level.FindOffscreenSpawnPoint(game)
-		AddGopnik(game, spawn.centerX, spawn.centerY)
-	}
-	for i := 0; i < missions[mission].maxWorms; i++ {
-		spawn := game.level.FindOffscreenSpawnPoint(game)
-		AddWorm(game, spawn.centerX, spawn.centerY)
-	}
-
-	for i := 0; i < missions[mission].maxBarrels; i++ {
-		spawn := game.level.FindOffscreenSpawnPoint(game)
-		AddBarrel(game, spawn.centerX, spawn.centerY)
+	for _, sc := range mis.spawns {
+		for i := 0; i < sc.max; i++ {
+			spawn := game.level.FindOffscreenSpawnPoint(game)
+			SpawnArchetype(game, sc.archetype, spawn.centerX, spawn.centerY)
+		}
 	}
+	SpawnPylonPickups(game)
 
 	audio.PlaySound("intro_chime")
 
 	if mission == 0 {
-		Listen_Signal(SIGNAL_PLAYER_MOVED, game)
-		Listen_Signal(SIGNAL_PLAYER_SHOT, game)
+		game.Track(Listen_Signal(func(ev PlayerMoved) { game.HandleTutorial(ev) }))
+		game.Track(Listen_Signal(func(ev PlayerShot) { game.HandleTutorial(ev) }))
+	}
+	game.Track(Listen_Signal(func(ev PlayerEdge) { game.HandleTutorial(ev) }))
+	game.Track(Listen_Signal(func(ev GameStarted) { game.HandleTutorial(ev) }))
+	game.Track(Listen_Signal(game.OnPlayerAscended))
+	game.Track(Listen_Signal(game.OnCatRule))
+	game.Track(Listen_Signal(game.OnCatDied))
+
+	if mission == len(missions) {
+		game.StartBossFight()
+	}
+
+	if __botPlaying {
+		AttachBot(game)
 	}
-	Listen_Signal(SIGNAL_PLAYER_EDGE, game)
-	Listen_Signal(SIGNAL_PLAYER_ASCEND, game)
-	Listen_Signal(SIGNAL_CAT_RULE, game)
-	Listen_Signal(SIGNAL_CAT_DIE, game)
-	Listen_Signal(SIGNAL_GAME_START, game)
 
 	return game
 }
@@ -184,7 +235,11 @@ func (g *Game) Update(deltaTime float64) {
 			}
 			if strings.Contains(cheatText, "tdnovymir") {
 				cheatText = ""
-				ChangeAppState(NewGame(g.missionNumber))
+				if g.endless != nil {
+					ChangeAppState(NewEndlessGame(g.endless))
+				} else {
+					ChangeAppState(NewGame(g.missionNumber))
+				}
 				return
 			}
 			if strings.Contains(cheatText, "tdcruoris") {
@@ -200,16 +255,21 @@ func (g *Game) Update(deltaTime float64) {
 				g.love = g.mission.loveQuota
 				ply := g.playerObj.components[0].(*Player)
 				ply.ascended = true
-				Emit_Signal(SIGNAL_PLAYER_ASCEND, g.playerObj, nil)
+				Emit_Signal(PlayerAscended{Player: g.playerObj})
 			}
 			if strings.Contains(cheatText, "tdgottam") {
 				cheatText = ""
-				ChangeAppState(NewGame(g.missionNumber + 1))
+				if g.endless != nil {
+					g.endless.stage++
+					ChangeAppState(NewEndlessGame(g.endless))
+				} else {
+					ChangeAppState(NewGame(g.missionNumber + 1))
+				}
 				return
 			}
 			if strings.Contains(cheatText, "tdspicy") {
 				cheatText = ""
-				AddWorm(g, g.playerObj.pos.X, g.playerObj.pos.Y)
+				SpawnArchetype(g, "worm", g.playerObj.pos.X, g.playerObj.pos.Y)
 			}
 
 			//Prevent the game from going AWOL when the window is moved
@@ -217,56 +277,21 @@ func (g *Game) Update(deltaTime float64) {
 				return
 			}
 
-			//Respawn monsters/barrels offscreen to maintain gameplay intensity
-			g.respawnTimer += g.deltaTime
-			if g.respawnTimer > 4.0 {
-				g.respawnTimer = 0.0
-
-				const (
-					S_KNIGHT = iota
-					S_BLARGH
-					S_GOPNIK
-					S_BARREL
-					S_WORM
-				)
-
-				pool := make([]int, 0, 4)
-				if knightCtr.count < g.mission.maxKnights {
-					pool = append(pool, S_KNIGHT)
-				}
-				if blarghCtr.count < g.mission.maxBlarghs {
-					pool = append(pool, S_BLARGH)
-				}
-				if gopnikCtr.count < g.mission.maxGopniks {
-					pool = append(pool, S_GOPNIK)
-				}
-				if barrelCtr.count < g.mission.maxBarrels {
-					pool = append(pool, S_BARREL)
-				}
-				if wormCtr.count < g.mission.maxWorms {
-					pool = append(pool, S_WORM)
-				}
+			//Signals emitted during the tick are delivered after the objects are done updating, so handlers can safely add and remove objects
+			Begin_Signal_Queue()
 
-				if len(pool) > 0 {
-					spawn := g.level.FindOffscreenSpawnPoint(g)
-					c := pool[rand.Intn(len(pool))]
-					switch c {
-					case S_KNIGHT:
-						AddKnight(g, spawn.centerX, spawn.centerY)
-					case S_BLARGH:
-						AddBlargh(g, spawn.centerX, spawn.centerY)
-					case S_GOPNIK:
-						AddGopnik(g, spawn.centerX, spawn.centerY)
-					case S_BARREL:
-						AddBarrel(g, spawn.centerX, spawn.centerY)
-					case S_WORM:
-						AddWorm(g, spawn.centerX, spawn.centerY)
-					}
-				}
+			//Respawn monsters/barrels offscreen to maintain gameplay intensity
+			g.director.Update(g)
+			g.playerField.Update(g.playerObj.pos)
+			g.catField.Update(g.playerObj.pos)
+			g.perception.Update(g)
+			g.flock.Update()
+			g.score.Update(g.deltaTime)
+			if g.endless != nil {
+				g.endless.Update(g)
 			}
 
 			//Update objects
-			toRemove := make([]*list.Element, 0, 4)
 			for objE := g.objects.Front(); objE != nil; objE = objE.Next() {
 				obj := objE.Value.(*Object)
 				//Update components
@@ -275,11 +300,9 @@ func (g *Game) Update(deltaTime float64) {
 						c.Update(g, obj)
 					}
 				}
-				//Objects are removed later so that they doesn't interfere with collision events
-				if obj.removeMe {
-					toRemove = append(toRemove, objE)
-				}
 			}
+			//Objects are removed later so that they doesn't interfere with collision events
+			toRemove := g.FlaggedObjects()
 			//Resolve inter-object collisions
 			for objE := g.objects.Front(); objE != nil; objE = objE.Next() {
 				obj := objE.Value.(*Object)
@@ -301,10 +324,9 @@ func (g *Game) Update(deltaTime float64) {
 					}
 				}
 			}
-			//Remove objects flagged for removal
-			for _, objE := range toRemove {
-				g.objects.Remove(objE)
-			}
+			g.RemoveObjects(toRemove)
+
+			End_Signal_Queue()
 
 			//Strobe background color by incrementing the timer in a "ping pong" motion.
 			if g.strobeForward {
@@ -350,11 +372,11 @@ func (g *Game) Update(deltaTime float64) {
 				g.fadeStage = 0
 				//If the level is ending, start a new game
 				if g.fade == FM_FADE_OUT {
-					ChangeAppState(NewCutsceneState(g.missionNumber + 1))
+					ChangeAppState(g.NextState())
 					return
 				} else {
 					runtime.GC() //Get rid of all that level generation memory
-					Emit_Signal(SIGNAL_GAME_START, g, nil)
+					Emit_Signal(GameStarted{Game: g})
 					audio.PlayMusic(g.mission.music)
 				}
 				g.fade = FM_NO_FADE
@@ -375,7 +397,7 @@ func (g *Game) CenterCameraOn(obj *Object, instant bool) {
 
 	if targetPos.X <= topLeft.X || targetPos.Y <= topLeft.Y || targetPos.X >= bottomRight.X || targetPos.Y >= bottomRight.Y {
 		//When bumping into the edge of the screen, notify the player that they can warp if this has not been done already.
-		Emit_Signal(SIGNAL_PLAYER_EDGE, g.playerObj, nil)
+		Emit_Signal(PlayerEdge{Player: g.playerObj})
 	}
 
 	camMove := targetPos.Clone().Sub(g.camPos)
@@ -398,17 +420,18 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	camMat.Translate(math.Floor(-g.camPos.X+SCR_WIDTH_H), math.Floor(-g.camPos.Y+SCR_HEIGHT_H))
 
 	g.level.Draw(g, screen, camMat)
+	g.renderQueue.Clear()
 	for objE := g.objects.Front(); objE != nil; objE = objE.Next() {
 		obj := objE.Value.(*Object)
-		if !obj.hidden && g.SquareOnScreen(obj.pos.X, obj.pos.Y, obj.radius) {
-			objM := &ebiten.DrawImageOptions{}
-			objM.GeoM.Concat(*camMat)
-			objM.GeoM.Translate(math.Floor(obj.pos.X), math.Floor(obj.pos.Y))
-			for _, spr := range obj.sprites {
-				spr.Draw(screen, &objM.GeoM)
-			}
+		if !obj.IsHidden() && g.SquareOnScreen(obj.pos.X, obj.pos.Y, obj.radius) {
+			g.renderQueue.Push(obj)
 		}
 	}
+	g.renderQueue.Sort()
+	g.renderQueue.Draw(screen, camMat)
+	if debugDraw {
+		DrawAIStates(g, screen)
+	}
 	if g.fade == FM_NO_FADE {
 		g.hud.Draw(screen)
 	}
@@ -430,77 +453,175 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 }
 
-// Number of signal emmissions before the tutorial messages are displayed
+// Number of times the player must do something before the tutorial messages are displayed
 const (
 	MOVE_SIGNAL_THRESHOLD  = 100
 	SHOOT_SIGNAL_THRESHOLD = 8
 )
 
-func (g *Game) HandleSignal(kind Signal, src interface{}, params map[string]interface{}) {
-	if !g.hud.IsDisplayingMessage() {
-		if g.missionNumber == 0 {
-			switch kind {
-			case SIGNAL_PLAYER_MOVED:
-				if g.tutorialStep == 0 && Get_Signal_Count(SIGNAL_PLAYER_MOVED) >= MOVE_SIGNAL_THRESHOLD {
-					g.hud.DisplayMessage("HOLDING CLICK/SPACE WILL SHOOT. ENTER   WILL PAUSE.", 5.0)
-					g.tutorialStep += 1
-				}
-			case SIGNAL_PLAYER_SHOT:
-				if g.tutorialStep == 1 && Get_Signal_Count(SIGNAL_PLAYER_SHOT) >= SHOOT_SIGNAL_THRESHOLD {
-					g.hud.DisplayMessage("THE MONSTERS PRODUCE FUEL FOR ASCENTION. FILL THE BAR!", 5.0)
-					g.tutorialStep += 1
-				}
-			case SIGNAL_GAME_START:
-				g.hud.DisplayMessage("MOVE WITH WASD KEYS OR ARROWS.", 4.0)
-			case SIGNAL_PLAYER_EDGE:
-				if g.tutorialStep == 2 && Get_Signal_Count(SIGNAL_PLAYER_EDGE) > 100 {
-					g.hud.DisplayMessage("PRESS INTO THE      BOUNDARY TO GET TO  THE OTHER SIDE.", 5.0)
-					g.tutorialStep += 1
-				}
+//Displays the tutorial messages in response to the player's actions
+func (g *Game) HandleTutorial(ev Event) {
+	if g.hud.IsDisplayingMessage() {
+		return
+	}
+	if g.missionNumber == 0 {
+		switch ev.(type) {
+		case PlayerMoved:
+			if g.tutorialStep == 0 && g.stats.MoveFrames >= MOVE_SIGNAL_THRESHOLD {
+				g.hud.DisplayMessage("HOLDING CLICK/SPACE WILL SHOOT. ENTER   WILL PAUSE.", 5.0)
+				g.tutorialStep += 1
+			}
+		case PlayerShot:
+			if g.tutorialStep == 1 && g.stats.ShotsFired >= SHOOT_SIGNAL_THRESHOLD {
+				g.hud.DisplayMessage("THE MONSTERS PRODUCE FUEL FOR ASCENTION. FILL THE BAR!", 5.0)
+				g.tutorialStep += 1
 			}
-		} else {
-			//We also display the edge dialog on future missions in case it is missed
-			if kind == SIGNAL_PLAYER_EDGE && g.tutorialStep == 0 && Get_Signal_Count(SIGNAL_PLAYER_EDGE) <= 100 {
+		case GameStarted:
+			g.hud.DisplayMessage("MOVE WITH WASD KEYS OR ARROWS.", 4.0)
+		case PlayerEdge:
+			if g.tutorialStep == 2 && g.stats.EdgeFrames > 100 {
 				g.hud.DisplayMessage("PRESS INTO THE      BOUNDARY TO GET TO  THE OTHER SIDE.", 5.0)
 				g.tutorialStep += 1
 			}
 		}
+	} else {
+		//We also display the edge dialog on future missions in case it is missed
+		if _, ok := ev.(PlayerEdge); ok && g.tutorialStep == 0 && __runStats.EdgeFrames <= 100 {
+			g.hud.DisplayMessage("PRESS INTO THE      BOUNDARY TO GET TO  THE OTHER SIDE.", 5.0)
+			g.tutorialStep += 1
+		}
+	}
+}
+
+func (g *Game) OnPlayerAscended(ev PlayerAscended) {
+	AddStarBurst(g, g.playerObj.pos.X, g.playerObj.pos.Y)
+	audio.PlaySound("ascend")
+	//The demon is the only target in its fight
+	if g.boss != nil {
+		return
+	}
+	spawn := g.level.FindOffscreenSpawnPoint(g)
+	AddCat(g, spawn.centerX, spawn.centerY)
+	if g.missionNumber == 0 {
+		g.hud.DisplayMessage("  EXCELLENT. NOW...     GO KILL THE CAT!", 4.0)
+	}
+}
+
+func (g *Game) OnCatRule(ev CatRule) {
+	g.hud.DisplayMessage("YOU MUST ASCEND TO  SLAY THE CAT", 4.0)
+}
+
+func (g *Game) OnCatDied(ev CatDied) {
+	g.fade = FM_FADE_OUT
+	audio.PlaySound("outro_chime")
+	g.completed = g.endless == nil || !g.endless.failed
+	//Cats can only show up in the demon fight through cheats, and that fight isn't in the mission table
+	if g.endless != nil || g.missionNumber >= len(missions) {
+		return
+	}
+	//The flag goes on the original mission, since that's what the endings look at
+	missions[g.missionNumber].goodEndFlag = g.elapsedTime < float64(g.mission.parTime)
+	RecordBestTime(g.missionNumber, g.difficulty, g.elapsedTime)
+}
+
+//Sets up the fight with the demon. The player starts out ascended, and loses when they're hit with an empty love meter.
+func (g *Game) StartBossFight() {
+	g.love = g.mission.loveQuota
+	g.playerObj.components[0].(*Player).ascended = true
+	spawn := g.level.FindSpawnPointInBand(g, SCR_HEIGHT_H, SCR_WIDTH)
+	if spawn == nil {
+		spawn = g.level.FindOffscreenSpawnPoint(g)
+	}
+	if spawn == nil {
+		spawn = g.level.FindSpawnPoint()
 	}
-	switch kind {
-	case SIGNAL_PLAYER_ASCEND:
-		spawn := g.level.FindOffscreenSpawnPoint(g)
-		AddCat(g, spawn.centerX, spawn.centerY)
-		AddStarBurst(g, g.playerObj.pos.X, g.playerObj.pos.Y)
-		audio.PlaySound("ascend")
-		if g.missionNumber == 0 {
-			g.hud.DisplayMessage("  EXCELLENT. NOW...     GO KILL THE CAT!", 4.0)
+	g.boss, _ = AddDemon(g, spawn.centerX, spawn.centerY)
+	g.Track(Listen_Signal(g.OnBossDefeated))
+	g.Track(Listen_Signal(func(ev LoveChanged) {
+		if ev.Old == 0 && ev.New == 0 && g.fade == FM_NO_FADE {
+			g.fade = FM_FADE_OUT
+			g.bossWon = false
+			audio.PlaySound("cat_die")
 		}
-	case SIGNAL_CAT_RULE:
-		g.hud.DisplayMessage("YOU MUST ASCEND TO  SLAY THE CAT", 4.0)
-	case SIGNAL_CAT_DIE:
+	}))
+}
+
+func (g *Game) OnBossDefeated(ev BossDefeated) {
+	if g.fade == FM_NO_FADE {
 		g.fade = FM_FADE_OUT
+		g.bossWon = true
+		g.completed = true
 		audio.PlaySound("outro_chime")
-		g.mission.goodEndFlag = g.elapsedTime < float64(g.mission.parTime)
+		RecordBestTime(g.missionNumber, g.difficulty, g.elapsedTime)
 	}
 }
 
-// Adds the object to the game, sorted by its draw priority, and returns the object
-func (g *Game) AddObject(newObj *Object) *Object {
-	for e := g.objects.Front(); e != nil; e = e.Next() {
-		obj := e.Value.(*Object)
-		if obj.drawPriority > newObj.drawPriority {
-			g.objects.InsertBefore(newObj, e)
-			return newObj
+//Returns what comes after the mission is over. Completed missions show the results first.
+func (g *Game) NextState() AppState {
+	next := func() AppState {
+		return NewCutsceneState(g.NextScene())
+	}
+	if g.endless != nil {
+		g.endless.score += g.score.Total()
+		next = g.endless.Next
+	}
+	if g.completed {
+		return NewResultsScreen(g, next)
+	}
+	return next()
+}
+
+//Returns the cutscene that plays after the mission is over
+func (g *Game) NextScene() int {
+	if g.boss != nil {
+		if g.bossWon {
+			return len(missions) + SCENE_BOSS_WIN
 		}
+		return len(missions) + SCENE_BOSS_LOSE
 	}
+	return g.missionNumber + 1
+}
+
+// Adds the object to the game and returns the object
+func (g *Game) AddObject(newObj *Object) *Object {
+	g.census.Add(newObj)
 	g.objects.PushBack(newObj)
 	return newObj
 }
 
+//Moves children to their parents, carrying over removal flags, and returns the objects that are flagged for removal
+func (g *Game) FlaggedObjects() []*list.Element {
+	//Start from the top of each hierarchy
+	for objE := g.objects.Front(); objE != nil; objE = objE.Next() {
+		obj := objE.Value.(*Object)
+		if obj.parent == nil {
+			obj.UpdateChildren()
+		}
+	}
+	toRemove := make([]*list.Element, 0, 4)
+	for objE := g.objects.Front(); objE != nil; objE = objE.Next() {
+		if objE.Value.(*Object).removeMe {
+			toRemove = append(toRemove, objE)
+		}
+	}
+	return toRemove
+}
+
+//Removes the objects returned by FlaggedObjects from the game and the census
+func (g *Game) RemoveObjects(toRemove []*list.Element) {
+	for _, objE := range toRemove {
+		obj := objE.Value.(*Object)
+		obj.Detach()
+		g.census.Remove(obj)
+		g.objects.Remove(objE)
+	}
+}
+
 // Adds to the love counter. Returns true if the operations causes the quota to be met.
 func (g *Game) IncLoveCounter(amt int) bool {
+	old := g.love
 	if g.love == g.mission.loveQuota {
-		Emit_Signal(SIGNAL_LOVE_CHANGE, g, map[string]interface{}{"newValue": g.love})
+		Emit_Signal(LoveChanged{Game: g, Old: old, New: g.love})
 		return true
 	}
 	if amt < 0 {
@@ -510,17 +631,18 @@ func (g *Game) IncLoveCounter(amt int) bool {
 	g.love += amt
 	if g.love >= g.mission.loveQuota {
 		g.love = g.mission.loveQuota
-		Emit_Signal(SIGNAL_LOVE_CHANGE, g, map[string]interface{}{"newValue": g.love})
+		Emit_Signal(LoveChanged{Game: g, Old: old, New: g.love})
 		return true
 	}
-	Emit_Signal(SIGNAL_LOVE_CHANGE, g, map[string]interface{}{"newValue": g.love})
+	Emit_Signal(LoveChanged{Game: g, Old: old, New: g.love})
 	return false
 }
 
 // Subtracts from the love counter. Returns true if the operation causes to counter to hit zero.
 func (g *Game) DecLoveCounter(amt int) bool {
+	old := g.love
 	if g.love == 0 {
-		Emit_Signal(SIGNAL_LOVE_CHANGE, g, map[string]interface{}{"newValue": g.love})
+		Emit_Signal(LoveChanged{Game: g, Old: old, New: g.love})
 		return true
 	}
 	if amt < 0 {
@@ -530,10 +652,10 @@ func (g *Game) DecLoveCounter(amt int) bool {
 	g.love -= amt
 	if g.love <= 0 {
 		g.love = 0
-		Emit_Signal(SIGNAL_LOVE_CHANGE, g, map[string]interface{}{"newValue": g.love})
+		Emit_Signal(LoveChanged{Game: g, Old: old, New: g.love})
 		return true
 	}
-	Emit_Signal(SIGNAL_LOVE_CHANGE, g, map[string]interface{}{"newValue": g.love})
+	Emit_Signal(LoveChanged{Game: g, Old: old, New: g.love})
 	return false
 }
 
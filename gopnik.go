@@ -18,7 +18,6 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
-	"image"
 	"math"
 	"math/rand"
 
@@ -28,52 +27,87 @@ import (
 
 type Gopnik struct {
 	Mob
-	shootTimer, shootAngle float64
+	arch       *Archetype
+	shootAngle float64
 }
 
-var sprGopnikNormal []*Sprite
-var sprGopnikHurt *Sprite
-var sprGopnikDie []*Sprite
-
-func init() {
-	sprGopnikNormal = NewSprites(vmath.NewVec(-8.0, -8.0), image.Rect(0, 64, 16, 80), image.Rect(16, 64, 32, 80))
-	sprGopnikHurt = NewSprite(image.Rect(32, 64, 48, 80), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprGopnikDie = NewSprites(vmath.NewVec(-8.0, -8.0), image.Rect(32, 64, 48, 80), image.Rect(48, 64, 64, 80))
-}
-
-var gopnikCtr *ObjCtr
-
-func init() {
-	gopnikCtr = NewObjCtr()
-}
-
-func AddGopnik(game *Game, x, y float64) *Object {
+func AddGopnik(game *Game, arch *Archetype, x, y float64) *Object {
 	//Despawn if it is in too tight a space
-	if hit, _, _ := game.level.SphereIntersects(vmath.NewVec(x, y), 15.0); hit {
+	if hit, _, _ := game.level.SphereIntersects(vmath.NewVec(x, y), arch.Param("clearance", 15.0)); hit {
 		return nil
 	}
 
 	gopnik := &Gopnik{
 		Mob: Mob{
-			Actor:  NewActor(50.0, 10_000.0, 100_000.0),
-			health: 6,
+			Actor:  NewActor(arch.maxSpeed, arch.acceleration, arch.friction),
+			vision: arch.vision,
+			health: arch.health,
 			currAnim: &Anim{
-				frames: sprGopnikNormal,
-				speed:  0.5,
+				frames: arch.sprites["normal"],
+				speed:  arch.animSpeed,
 				loop:   true,
 			},
 			lastSeenPlayerPos: vmath.ZeroVec(),
 			vecToPlayer:       vmath.ZeroVec(),
 		},
-		shootTimer: rand.Float64()/2.0 + 0.5,
+		arch:       arch,
 		shootAngle: rand.Float64() * math.Pi * 2.0,
 	}
-	gopnikCtr.Inc()
-	return game.AddObject(&Object{
-		pos: vmath.NewVec(x, y), radius: 7.0, colType: CT_ENEMY,
-		sprites:    []*Sprite{sprGopnikNormal[0]},
+	obj := &Object{
+		pos: vmath.NewVec(x, y), radius: arch.radius, colType: CT_ENEMY,
+		archetype:  arch.name,
+		sprites:    []*Sprite{arch.Sprite("normal")},
 		components: []Component{gopnik},
+	}
+	gopnik.ai = gopnik.NewAI(game, obj)
+	return game.AddObject(obj)
+}
+
+//Gopniks stay put and fire rings of shots once they've seen the player
+func (gp *Gopnik) NewAI(game *Game, obj *Object) *StateMachine {
+	atk := &gp.arch.attack
+	sm := NewStateMachine(AI_IDLE)
+	sm.Define(AI_IDLE, StateDef{
+		Update: func(game *Game, obj *Object) {
+			if gp.hunting {
+				sm.Change(game, obj, AI_ALERT)
+				sm.Advance(rand.Float64()/2.0 + 0.5)
+			}
+		},
 	})
+	sm.Define(AI_ALERT, StateDef{
+		Timeout: atk.Interval,
+		Next:    AI_ATTACK,
+	})
+	//Shots are spread evenly around the gopnik
+	sm.Define(AI_ATTACK, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			for i := 0; i < atk.Shots; i++ {
+				a := float64(i) * math.Pi * 2.0 / float64(atk.Shots)
+				AddShot(game, obj.pos.Clone(), vmath.VecFromAngle(gp.shootAngle+a, 1.0), atk.ShotSpeed, true)
+			}
+			gp.shootAngle += atk.Spin
+		},
+		Timeout: atk.Interval,
+		Next:    AI_ATTACK,
+	})
+	sm.Define(AI_DYING, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			gp.dead = true
+			audio.PlaySound("enemy_die")
+			gp.currAnim = &Anim{
+				frames: gp.arch.sprites["die"],
+				speed:  gp.arch.dieSpeed,
+				callback: func(anm *Anim) {
+					if anm.finished {
+						obj.removeMe = true
+						gp.arch.DropLoot(game, obj.pos.X, obj.pos.Y)
+					}
+				},
+			}
+		},
+	})
+	return sm
 }
 
 func (gp *Gopnik) Update(game *Game, obj *Object) {
@@ -81,18 +115,7 @@ func (gp *Gopnik) Update(game *Game, obj *Object) {
 	gp.Actor.Update(game, obj)
 
 	if gp.hurtTimer > 0.0 {
-		obj.sprites[0] = sprGopnikHurt
-	}
-
-	if gp.hunting {
-		gp.shootTimer += game.deltaTime
-		if gp.shootTimer > 2.0 {
-			gp.shootTimer = 0.0
-			for a := 0.0; a < math.Pi*2.0; a += math.Pi / 2.0 {
-				AddShot(game, obj.pos.Clone(), vmath.VecFromAngle(gp.shootAngle+a, 1.0), 40.0, true)
-			}
-			gp.shootAngle += math.Pi / 8.0
-		}
+		obj.sprites[0] = gp.arch.Sprite("hurt")
 	}
 }
 
@@ -101,18 +124,6 @@ func (gp *Gopnik) OnCollision(game *Game, obj, other *Object) {
 
 	//Death
 	if gp.health <= 0 && !gp.dead {
-		gp.dead = true
-		audio.PlaySound("enemy_die")
-		gp.currAnim = &Anim{
-			frames: sprGopnikDie,
-			speed:  0.15,
-			callback: func(anm *Anim) {
-				if anm.finished {
-					obj.removeMe = true
-					gopnikCtr.Dec()
-					AddLove(game, 5, obj.pos.X, obj.pos.Y)
-				}
-			},
-		}
+		gp.ai.Change(game, obj, AI_DYING)
 	}
 }
@@ -20,6 +20,7 @@ package main
 import (
 	"fmt"
 	"image"
+	"math"
 	// "image/color"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -29,6 +30,9 @@ import (
 type GameHUD struct {
 	root      *UINode
 	loveBar   *UIBox
+	bossBar   *UIBox //Health of the demon
+	powerText *UIText //Lists the player's power-ups and their time left
+	scoreText *UIText //Score and combo multiplier, along with the stage in endless mode
 	msgText   *UIText
 	msgTimer  float64
 	timerText *UIText
@@ -37,10 +41,14 @@ type GameHUD struct {
 	pause     PauseScreen
 	control   ControlsScreen
 	loveShowTimer float64
+	toastText *UIText
+	toastTimer float64
+	toastQueue []*Achievement //Achievements waiting for their turn to be announced
 }
 
 type PauseScreen struct {
 	container    *UIBox
+	statsText    *UIText
 	controlsButt *UIBox
 	restartButt  *UIBox
 	musicButt    *UIBox
@@ -52,7 +60,8 @@ type ControlsScreen struct {
 	backButt  *UIBox
 }
 
-func CreateGameHUD() *GameHUD {
+//Creates the in-game HUD. Its subscriptions are added to the given scope.
+func CreateGameHUD(scope *SignalScope) *GameHUD {
 	hud := &GameHUD{}
 	hud.root = EmptyUINode()
 
@@ -66,6 +75,12 @@ func CreateGameHUD() *GameHUD {
 	hud.loveBar = CreateUIBox(image.Rect(104, 40, 112, 48), image.Rect(4, 4, loveBorder.Width()-4, loveBorder.Height()-4), false)
 	loveBorder.AddChild(&hud.loveBar.UINode)
 
+	bossBorder := CreateUIBox(image.Rect(64, 40, 88, 48), image.Rect(SCR_WIDTH_H-76, 24, SCR_WIDTH_H+76, 40), true)
+	bossBorder.visible = false
+	hud.root.AddChild(&bossBorder.UINode)
+	hud.bossBar = CreateUIBox(image.Rect(104, 40, 112, 48), image.Rect(4, 4, bossBorder.Width()-4, bossBorder.Height()-4), false)
+	bossBorder.AddChild(&hud.bossBar.UINode)
+
 	msgBorder := CreateUIBox(image.Rect(112, 40, 136, 48), image.Rect(SCR_WIDTH_H-88, SCR_HEIGHT-48, SCR_WIDTH_H+88, SCR_HEIGHT-8), true)
 	// msgBorder := CreateUIBox(image.Rect(64, 0, 64, 0), image.Rect(SCR_WIDTH_H-88, SCR_HEIGHT-48, SCR_WIDTH_H+88, SCR_HEIGHT-8), false)
 	msgBorder.visible = false
@@ -79,6 +94,20 @@ func CreateGameHUD() *GameHUD {
 	hud.timerText = GenerateText("00:00/00:00", image.Rect(4, 4, 2048, 2048))
 	timerBorder.AddChild(&hud.timerText.UINode)
 
+	//One line of ten characters per power-up, under the timer
+	hud.powerText = GenerateText("", image.Rect(SCR_WIDTH-84, 24, SCR_WIDTH-4, 24+8*int(PU_COUNT)))
+	hud.root.AddChild(&hud.powerText.UINode)
+
+	//Two lines of twenty characters, under the love bar
+	hud.scoreText = GenerateText("", image.Rect(4, 24, 4+160, 40))
+	hud.root.AddChild(&hud.scoreText.UINode)
+
+	toastBorder := CreateUIBox(image.Rect(112, 40, 136, 48), image.Rect(SCR_WIDTH_H-88, 24, SCR_WIDTH_H+88, 48), true)
+	toastBorder.visible = false
+	hud.root.AddChild(&toastBorder.UINode)
+	hud.toastText = GenerateText("", image.Rect(8, 4, toastBorder.Width()-8, toastBorder.Height()-4))
+	toastBorder.AddChild(&hud.toastText.UINode)
+
 	hud.fpsText = GenerateText("FPS: 00", image.Rect(SCR_WIDTH-80, 0, SCR_WIDTH, 64))
 	hud.root.AddChild(&hud.fpsText.UINode)
 
@@ -115,6 +144,9 @@ func CreateGameHUD() *GameHUD {
 
 	muteContainer.ArrangeChildren(image.Rect(0, 0, 0, 0), false)
 
+	hud.pause.statsText = GenerateText("", image.Rect(0, 0, 152, 16))
+	hud.pause.container.AddChild(&hud.pause.statsText.UINode)
+
 	hud.pause.controlsButt = CreateUIBox(image.Rect(88, 40, 112, 48), image.Rect(0, 0, 108, 16), true) //Controls button
 	hud.pause.controlsButt.AddChild(&GenerateText("HELP", image.Rect(4, 4, 2048, 2048)).UINode)
 	hud.pause.container.AddChild(&hud.pause.controlsButt.UINode)
@@ -160,19 +192,32 @@ func CreateGameHUD() *GameHUD {
 
 	hud.menu.AddChild(&hud.control.container.UINode)
 
-	Listen_Signal(SIGNAL_LOVE_CHANGE, hud)
+	scope.Track(Listen_Signal(hud.OnLoveChanged))
+	scope.Track(Listen_Signal(hud.OnAchievementUnlocked))
 
 	return hud
 }
 
 func (hud *GameHUD) Update(game *Game) {
 	if game.pause {
+		if !hud.menu.visible {
+			//Show this mission's stats on the pause screen
+			kills := fmt.Sprintf("KILLS: %d", game.stats.TotalKills())
+			accuracy := fmt.Sprintf("ACCURACY: %d%%", int(game.stats.Accuracy()*100.0))
+			hud.pause.statsText.text = fmt.Sprintf("%-19s%-19s", kills, accuracy)
+			hud.pause.statsText.fillPos = len(hud.pause.statsText.text)
+			hud.pause.statsText.Regen()
+		}
 		hud.menu.visible = true
 		if hud.pause.container.visible {
 			//Respond to pause screen buttons
 			if hud.pause.restartButt.Clicked() {
 				audio.PlaySound("button")
-				ChangeAppState(NewGame(0))
+				if game.endless != nil {
+					ChangeAppState(NewEndlessGame(NewEndlessRun()))
+				} else {
+					ChangeAppState(NewGame(0))
+				}
 			} else if hud.pause.sfxButt.Clicked() {
 				audio.PlaySound("button")
 				audio.MuteSfx = !audio.MuteSfx
@@ -202,7 +247,8 @@ func (hud *GameHUD) Update(game *Game) {
 		//Hide love bar if player is under it
 		// hud.loveBar.parent.visible = (game.playerObj.pos.X > 164 || game.playerObj.pos.Y > 64)
 		// hud.timerText.parent.visible = hud.loveBar.parent.visible
-		if hud.loveShowTimer > 0.0 {
+		//The love meter is the player's life during the fight with the demon, so it stays up
+		if hud.loveShowTimer > 0.0 || game.boss != nil {
 			hud.loveShowTimer -= game.deltaTime
 			hud.loveBar.parent.visible = true
 		} else {
@@ -217,6 +263,50 @@ func (hud *GameHUD) Update(game *Game) {
 		hud.loveBar.dest = barRect
 		hud.loveBar.Regen()
 
+		//Update the demon's health bar
+		hud.bossBar.parent.visible = game.boss != nil && !game.boss.dead
+		if hud.bossBar.parent.visible {
+			barRect = image.Rect(3, 3, hud.bossBar.parent.Width()-3, hud.bossBar.parent.Height()-3)
+			barRect.Max.X = barRect.Min.X + int(float64(barRect.Size().X)*game.boss.HealthPercent())
+			hud.bossBar.dest = barRect
+			hud.bossBar.Regen()
+		}
+
+		//List active power-ups. The level is shown after the name when it's above one.
+		powers := ""
+		player := game.playerObj.components[0].(*Player)
+		for i, def := range powerUpDefs {
+			if lvl := player.powerUps.Level(PowerUpType(i)); lvl > 0 {
+				lvlText := ""
+				if lvl > 1 {
+					lvlText = fmt.Sprint(lvl)
+				}
+				powers += fmt.Sprintf("%-6s%1s%3d", def.name, lvlText, int(math.Ceil(player.powerUps[i].timer)))
+			}
+		}
+		if powers != hud.powerText.text {
+			hud.powerText.text = powers
+			hud.powerText.fillPos = len(powers)
+			hud.powerText.Regen()
+		}
+
+		//Update score
+		score := fmt.Sprintf("SCORE %d", game.score.Total())
+		if game.endless != nil {
+			score = fmt.Sprintf("SCORE %d", game.endless.score+game.score.Total())
+		}
+		if mult := game.score.Multiplier(); mult > 1 {
+			score += fmt.Sprintf(" X%d", mult)
+		}
+		if game.endless != nil {
+			score = fmt.Sprintf("%-20sSTAGE %d", score, game.endless.stage)
+		}
+		if score != hud.scoreText.text {
+			hud.scoreText.text = score
+			hud.scoreText.fillPos = len(score)
+			hud.scoreText.Regen()
+		}
+
 		//Update gameplay timer
 		tSeconds := int(game.elapsedTime) % 60
 		tMinutes := int(game.elapsedTime / 60.0)
@@ -225,6 +315,19 @@ func (hud *GameHUD) Update(game *Game) {
 		hud.timerText.text = fmt.Sprintf("%02d:%02d/%02d:%02d", tMinutes, tSeconds, pMinutes, pSeconds)
 		hud.timerText.Regen()
 
+		//Announce unlocked achievements one at a time
+		if hud.toastTimer > 0.0 {
+			hud.toastTimer -= game.deltaTime
+		} else if len(hud.toastQueue) > 0 {
+			hud.toastText.text = fmt.Sprintf("%-20s%s", "ACHIEVEMENT UNLOCKED", hud.toastQueue[0].name)
+			hud.toastText.Regen()
+			hud.toastText.fillPos = len(hud.toastText.text)
+			hud.toastQueue = hud.toastQueue[1:]
+			hud.toastTimer = ACHIEVEMENT_TOAST_TIME
+			audio.PlaySound("ascend")
+		}
+		hud.toastText.parent.visible = hud.toastTimer > 0.0
+
 		//Update message timer
 		if hud.msgText != nil && hud.msgTimer > 0.0 {
 			hud.msgText.parent.visible = true
@@ -238,7 +341,7 @@ func (hud *GameHUD) Update(game *Game) {
 	//FPS counter
 	if debugDraw {
 		hud.fpsText.visible = true
-		hud.fpsText.text = fmt.Sprintf("FPS: %.2f", ebiten.CurrentFPS())
+		hud.fpsText.text = fmt.Sprintf("%-10s%-20s%s", fmt.Sprintf("FPS: %.2f", ebiten.CurrentFPS()), game.director.DebugString(), game.perception.DebugString())
 		hud.fpsText.Regen()
 	} else {
 		hud.fpsText.visible = false
@@ -246,11 +349,14 @@ func (hud *GameHUD) Update(game *Game) {
 }
 
 const LOVE_SHOW_LAG = 2.0 //Time in seconds that the love bar lingers after showing up
+const ACHIEVEMENT_TOAST_TIME = 3.0 //Time in seconds that achievement announcements stay on screen
 
-func (hud *GameHUD) HandleSignal(kind Signal, src interface{}, params map[string]interface{}) {
-	if kind == SIGNAL_LOVE_CHANGE {
-		hud.loveShowTimer = LOVE_SHOW_LAG
-	}
+func (hud *GameHUD) OnLoveChanged(ev LoveChanged) {
+	hud.loveShowTimer = LOVE_SHOW_LAG
+}
+
+func (hud *GameHUD) OnAchievementUnlocked(ev AchievementUnlocked) {
+	hud.toastQueue = append(hud.toastQueue, ev.Achievement)
 }
 
 func (hud *GameHUD) Draw(screen *ebiten.Image) {
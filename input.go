@@ -0,0 +1,65 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+//What the player is told to do for one update
+type PlayerInput struct {
+	Move *vmath.Vec2f //Direction to move in. Zero means stand still.
+	Aim  *vmath.Vec2f //If set, shoot in this direction
+	Fire bool         //Shoot in the same direction as last time, or the way the player is facing
+}
+
+//Decides what the player does. The player asks its input source every update instead of reading the devices itself,
+//so that it can be driven by something other than a person.
+type InputSource interface {
+	PlayerInput(game *Game, obj *Object) PlayerInput
+}
+
+//Reads the player's input from the keyboard and mouse
+type KeyboardInput struct{}
+
+func (ki *KeyboardInput) PlayerInput(game *Game, obj *Object) PlayerInput {
+	var in PlayerInput
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) { //Shoot in direction of mouse click
+		cx, cy := ebiten.CursorPosition()
+		rPos := obj.pos.Clone().Sub(game.camPos).Add(vmath.NewVec(SCR_WIDTH_H, SCR_HEIGHT_H))
+		in.Aim = (vmath.NewVec(float64(cx), float64(cy))).Sub(rPos)
+	} else if ebiten.IsKeyPressed(ebiten.KeySpace) { //Or shoot in direction of last movement
+		in.Fire = true
+	}
+
+	var dx, dy float64
+	if ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
+		dy = -1.0
+	} else if ebiten.IsKeyPressed(ebiten.KeyDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
+		dy = 1.0
+	}
+
+	if ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
+		dx = 1.0
+	} else if ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
+		dx = -1.0
+	}
+	in.Move = vmath.NewVec(dx, dy)
+	return in
+}
@@ -18,7 +18,7 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
-	"image"
+	"math"
 	"math/rand"
 
 	"github.com/thetophatdemon/feta-feles-rebirth/audio"
@@ -27,71 +27,139 @@ import (
 
 type Knight struct {
 	Mob
-	chargeTimer float64
+	arch *Archetype
 }
 
-var sprKnightNormal *Sprite
-var sprKnightCharge *Sprite
-var sprKnightHurt *Sprite
-var sprKnightDie []*Sprite
-
-func init() {
-	sprKnightNormal = NewSprite(image.Rect(16, 32, 32, 48), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprKnightCharge = NewSprite(image.Rect(0, 32, 16, 48), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprKnightHurt = NewSprite(image.Rect(32, 32, 48, 48), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprKnightDie = NewSprites(vmath.NewVec(-8.0, -8.0), image.Rect(32, 32, 48, 48), image.Rect(48, 32, 64, 48))
-}
-
-var knightCtr *ObjCtr
-
-func init() {
-	knightCtr = NewObjCtr()
-}
-
-func AddKnight(game *Game, x, y float64) *Knight {
+func AddKnight(game *Game, arch *Archetype, x, y float64) *Object {
+	//The mission can override how fast knights charge
+	speed := arch.maxSpeed
+	if game.mission.knightSpeed > 0.0 {
+		speed = game.mission.knightSpeed
+	}
 	knight := &Knight{
 		Mob: Mob{
-			Actor:             NewActor(game.mission.knightSpeed, 200_000.0, 25_000.0),
-			health:            3,
+			Actor:             NewActor(speed, arch.acceleration, arch.friction),
+			vision:            arch.vision,
+			steering:          arch.steering,
+			health:            arch.health,
 			currAnim:          nil,
 			lastSeenPlayerPos: vmath.ZeroVec(),
 			vecToPlayer:       vmath.ZeroVec(),
 		},
-		chargeTimer: rand.Float64(),
+		arch: arch,
 	}
-	game.AddObject(&Object{
-		pos: vmath.NewVec(x, y), radius: 6.0, colType: CT_ENEMY,
-		sprites:    []*Sprite{sprKnightNormal},
+	obj := &Object{
+		pos: vmath.NewVec(x, y), radius: arch.radius, colType: CT_ENEMY,
+		archetype:  arch.name,
+		sprites:    []*Sprite{arch.Sprite("normal")},
 		components: []Component{knight},
+	}
+	knight.ai = knight.NewAI(game, obj)
+	return game.AddObject(obj)
+}
+
+//Knights stand still until they see the player, then charge at them in short bursts
+func (kn *Knight) NewAI(game *Game, obj *Object) *StateMachine {
+	atk := &kn.arch.attack
+	sm := NewStateMachine(AI_IDLE)
+	sm.Define(AI_IDLE, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			kn.Move(0.0, 0.0)
+		},
+		Update: func(game *Game, obj *Object) {
+			if kn.hunting {
+				sm.Change(game, obj, AI_CHASE)
+				sm.Advance(rand.Float64()) //Keep knights that notice the player together from charging in sync
+			} else if kn.heardPos != nil {
+				sm.Change(game, obj, AI_SEARCH)
+			}
+		},
 	})
-	knightCtr.Inc()
-	return knight
+	//Checking out a noise
+	sm.Define(AI_SEARCH, StateDef{
+		Update: func(game *Game, obj *Object) {
+			if kn.hunting {
+				sm.Change(game, obj, AI_CHASE)
+				return
+			}
+			dir := kn.Investigate(game, obj)
+			kn.Move(dir.X, dir.Y)
+			if kn.heardPos == nil {
+				sm.Change(game, obj, AI_IDLE)
+			}
+		},
+		Exit: func(game *Game, obj *Object) {
+			kn.heardPos = nil
+		},
+		Timeout: NOISE_SEARCH_TIME,
+		Next:    AI_IDLE,
+	})
+	//Waiting to charge again
+	sm.Define(AI_CHASE, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			kn.Move(0.0, 0.0)
+		},
+		Timeout: atk.Interval - atk.Duration,
+		Next:    AI_ATTACK,
+	})
+	//Charging
+	sm.Define(AI_ATTACK, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			//Packs spread out as they charge so that they come at the player from different sides
+			diff := kn.Steer(game, obj, kn.ChaseDirection(game, obj))
+			kn.Move(diff.X, diff.Y)
+		},
+		Update: func(game *Game, obj *Object) {
+			if !kn.seesPlayer {
+				//Steer around walls on the way to where the player was last seen
+				diff := kn.Steer(game, obj, kn.ChaseDirection(game, obj))
+				kn.Move(diff.X, diff.Y)
+			}
+		},
+		Timeout: atk.Duration,
+		Next:    AI_CHASE,
+	})
+	sm.Define(AI_DYING, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			kn.dead = true
+			kn.Move(0.0, 0.0)
+			audio.PlaySound("enemy_die")
+			kn.currAnim = &Anim{
+				frames: kn.arch.sprites["die"],
+				speed:  kn.arch.dieSpeed,
+				callback: func(anm *Anim) {
+					if anm.finished {
+						obj.removeMe = true
+						kn.arch.DropLoot(game, obj.pos.X, obj.pos.Y)
+					}
+				},
+			}
+		},
+	})
+	return sm
+}
+
+//Returns the time since the knight last started charging
+func (kn *Knight) timeSinceCharge() float64 {
+	switch kn.ai.State() {
+	case AI_ATTACK:
+		return kn.ai.Time()
+	case AI_CHASE:
+		return kn.arch.attack.Duration + kn.ai.Time()
+	}
+	return math.Inf(1)
 }
 
 func (kn *Knight) Update(game *Game, obj *Object) {
 	if kn.hurtTimer > 0.0 {
-		obj.sprites[0] = sprKnightHurt
-	} else if kn.chargeTimer < 1.0 {
-		obj.sprites[0] = sprKnightCharge
+		obj.sprites[0] = kn.arch.Sprite("hurt")
+	} else if kn.timeSinceCharge() < kn.arch.attack.Windup {
+		obj.sprites[0] = kn.arch.Sprite("attack")
 	} else {
-		obj.sprites[0] = sprKnightNormal
+		obj.sprites[0] = kn.arch.Sprite("normal")
 	}
 
 	kn.Mob.Update(game, obj)
-
-	if kn.hunting {
-		kn.chargeTimer += game.deltaTime
-		if kn.chargeTimer > 2.0 {
-			kn.chargeTimer = 0.0
-			diff := kn.lastSeenPlayerPos.Clone().Sub(obj.pos)
-			kn.Move(diff.X, diff.Y)
-		} else if kn.chargeTimer > 0.25 {
-			kn.Move(0.0, 0.0)
-		}
-	} else {
-		kn.Move(0.0, 0.0)
-	}
-
 	kn.Actor.Update(game, obj)
 }
 
@@ -100,18 +168,6 @@ func (kn *Knight) OnCollision(game *Game, obj, other *Object) {
 
 	//Death
 	if kn.health <= 0 && !kn.dead {
-		kn.dead = true
-		audio.PlaySound("enemy_die")
-		kn.currAnim = &Anim{
-			frames: sprKnightDie,
-			speed:  0.15,
-			callback: func(anm *Anim) {
-				if anm.finished {
-					obj.removeMe = true
-					knightCtr.Dec()
-					AddLove(game, 3, obj.pos.X, obj.pos.Y)
-				}
-			},
-		}
+		kn.ai.Change(game, obj, AI_DYING)
 	}
 }
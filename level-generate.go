@@ -30,16 +30,16 @@ const (
 func PropagateBlob(level *Level, x, y int, spreadChance float64) {
 	level.SetTile(x, y, TT_BLOCK, true)
 	if spreadChance > 0.0 {
-		if rand.Float64() < spreadChance {
+		if level.rng.Float64() < spreadChance {
 			PropagateBlob(level, x-1, y, spreadChance-SPREAD_DELTA)
 		}
-		if rand.Float64() < spreadChance {
+		if level.rng.Float64() < spreadChance {
 			PropagateBlob(level, x+1, y, spreadChance-SPREAD_DELTA)
 		}
-		if rand.Float64() < spreadChance {
+		if level.rng.Float64() < spreadChance {
 			PropagateBlob(level, x, y-1, spreadChance-SPREAD_DELTA)
 		}
-		if rand.Float64() < spreadChance {
+		if level.rng.Float64() < spreadChance {
 			PropagateBlob(level, x, y+1, spreadChance-SPREAD_DELTA)
 		}
 	}
@@ -57,16 +57,16 @@ func PropagateRune(level *Level, x, y int, dir int, life int) {
 		} else if dir == 3 && level.GetTile(x, y+1, true).tt == TT_BLOCK {
 			PropagateRune(level, x, y+1, dir, life-1)
 		}
-		if rand.Float32() < 0.2 {
+		if level.rng.Float32() < 0.2 {
 			var nd int
 			if dir == 2 || dir == 0 {
-				if rand.Float32() > 0.5 {
+				if level.rng.Float32() > 0.5 {
 					nd = 1
 				} else {
 					nd = 3
 				}
 			} else {
-				if rand.Float32() > 0.5 {
+				if level.rng.Float32() > 0.5 {
 					nd = 2
 				} else {
 					nd = 0
@@ -203,7 +203,7 @@ func (level *Level) DigTunnel(start *Tile, end *Tile) {
 
 		var direction bool //False for moving in x, true for moving in Y
 		if dxCount > 0 && dyCount > 0 {
-			direction = rand.Float64() < 0.5
+			direction = level.rng.Float64() < 0.5
 		} else if dyCount > 0 {
 			direction = true
 		} else if dxCount > 0 {
@@ -214,22 +214,24 @@ func (level *Level) DigTunnel(start *Tile, end *Tile) {
 			currTile = level.GetTile(currTile.gridX, currTile.gridY+sdy, true)
 			dyCount--
 			//Add some unevenness
-			if rand.Float64() < 0.25 {
-				level.SetTile(currTile.gridX+rand.Intn(3)-1, currTile.gridY, TT_EMPTY, true)
+			if level.rng.Float64() < 0.25 {
+				level.SetTile(currTile.gridX+level.rng.Intn(3)-1, currTile.gridY, TT_EMPTY, true)
 			}
 		} else {
 			currTile = level.GetTile(currTile.gridX+sdx, currTile.gridY, true)
 			dxCount--
 			//Add some unevenness
-			if rand.Float64() < 0.25 {
-				level.SetTile(currTile.gridX, currTile.gridY+rand.Intn(3)-1, TT_EMPTY, true)
+			if level.rng.Float64() < 0.25 {
+				level.SetTile(currTile.gridX, currTile.gridY+level.rng.Intn(3)-1, TT_EMPTY, true)
 			}
 		}
 	}
 }
 
-func GenerateLevel(w, h int, simple bool) *Level {
+//Generates a level from the seed. The same seed and settings always make the same level.
+func GenerateLevel(w, h int, simple bool, seed int64) *Level {
 	level := NewLevel(w, h)
+	level.rng = rand.New(rand.NewSource(seed))
 
 	//Generate borders
 	/*for x := 0; x < w; x++ {
@@ -248,7 +250,7 @@ func GenerateLevel(w, h int, simple bool) *Level {
 		blobFactor = 64
 	}
 	for k := 0; k < w*h/blobFactor; k++ {
-		x, y := rand.Intn(w), rand.Intn(h)
+		x, y := level.rng.Intn(w), level.rng.Intn(h)
 		PropagateBlob(level, x, y, 1.0)
 	}
 
@@ -35,6 +35,10 @@ type Level struct {
 	rows, cols              int
 	pixelWidth, pixelHeight float64
 	recalcEdges             bool //Flag for when edges need to be recalculated
+	navVersion              int  //Incremented whenever tiles change, so that paths through them can be thrown out
+	pathCache               map[pathKey][]*vmath.Vec2f
+	pathCacheVersion        int        //Value of navVersion when the cached paths were found
+	rng                     *rand.Rand //Seeded source of randomness for generating the level, so that it can be reproduced
 }
 
 func NewLevel(cols, rows int) *Level {
@@ -60,7 +64,14 @@ func NewLevel(cols, rows int) *Level {
 	pixelWidth := float64(cols * TILE_SIZE)
 	pixelHeight := float64(rows * TILE_SIZE)
 
-	return &Level{tiles, make([]*Space, 0, 10), rows, cols, pixelWidth, pixelHeight, false}
+	return &Level{
+		tiles:       tiles,
+		spaces:      make([]*Space, 0, 10),
+		rows:        rows,
+		cols:        cols,
+		pixelWidth:  pixelWidth,
+		pixelHeight: pixelHeight,
+	}
 }
 
 func (level *Level) WrapGridCoords(x, y int) (int, int) {
@@ -89,6 +100,34 @@ func (level *Level) WrapPixelCoords(x, y float64) (float64, float64) {
 	return x, y
 }
 
+// Returns true if something at the position is pushing in the direction against an edge of the map it could warp across
+func (level *Level) AtWarpEdge(pos *vmath.Vec2f, radius, dx, dy float64) bool {
+	return (pos.X <= radius+4 && dx < 0) || (pos.X >= level.pixelWidth-radius-4 && dx > 0) ||
+		(pos.Y <= radius+4 && dy < 0) || (pos.Y >= level.pixelHeight-radius-4 && dy > 0)
+}
+
+// Returns where something at the edge of the map pushing in the direction would end up after warping to the other side.
+// Returns nil if it's not at an edge or if the other side is blocked.
+func (level *Level) WarpDestination(pos *vmath.Vec2f, radius, dx, dy float64) *vmath.Vec2f {
+	var check, dest *vmath.Vec2f
+	switch {
+	case pos.X <= radius+4 && dx < 0:
+		check, dest = vmath.NewVec(level.pixelWidth-radius-1, pos.Y), vmath.NewVec(level.pixelWidth-radius, pos.Y)
+	case pos.X >= level.pixelWidth-radius-4 && dx > 0:
+		check, dest = vmath.NewVec(radius+1, pos.Y), vmath.NewVec(radius, pos.Y)
+	case pos.Y <= radius+4 && dy < 0:
+		check, dest = vmath.NewVec(pos.X, level.pixelHeight-radius-1), vmath.NewVec(pos.X, level.pixelHeight-radius)
+	case pos.Y >= level.pixelHeight-radius-4 && dy > 0:
+		check, dest = vmath.NewVec(pos.X, radius+1), vmath.NewVec(pos.X, radius)
+	default:
+		return nil
+	}
+	if hit, _, _ := level.SphereIntersects(check, radius); hit {
+		return nil
+	}
+	return dest
+}
+
 // Sets the tile at the coordinate to specified type. Returns true if coordinate is valid. If wrap is set, out of bounds coordinates will be offset to the other side of the level.
 func (level *Level) SetTile(x, y int, newType TileType, wrap bool) bool {
 	if wrap {
@@ -97,6 +136,7 @@ func (level *Level) SetTile(x, y int, newType TileType, wrap bool) bool {
 		return false
 	}
 	level.tiles[y][x].SetType(newType)
+	level.navVersion++
 	return true
 }
 
@@ -106,6 +146,7 @@ func (level *Level) DestroyTile(t *Tile) {
 		level.recalcEdges = true
 	}
 	t.SetType(TT_EMPTY)
+	level.navVersion++
 }
 
 // Gets a reference to the tile at the coordinates. Returns nil if out of bounds unless wrap is enabled.
@@ -129,16 +170,29 @@ func (level *Level) FindSpawnPoint() *Tile {
 			}
 		}
 	}
-	return emptyTiles[rand.Intn(len(emptyTiles))]
+	return emptyTiles[level.rng.Intn(len(emptyTiles))]
 }
 
 // Randomly chooses an empty tile that is off screen
 func (level *Level) FindOffscreenSpawnPoint(game *Game) *Tile {
+	return level.FindSpawnPointInBand(game, 0.0, math.Inf(1))
+}
+
+// Randomly chooses an empty tile that is off screen and between minDist and maxDist pixels away from the player
+func (level *Level) FindSpawnPointInBand(game *Game, minDist, maxDist float64) *Tile {
 	emptyTiles := make([]*Tile, 0, 1024)
 	for _, sp := range level.spaces {
 		for _, t := range sp.tiles {
 			//Find empty and off-screen tiles
 			if t.tt == TT_EMPTY && !game.SquareOnScreen(t.centerX, t.centerY, TILE_SIZE_H) {
+				//Skip tile if it's outside of the distance band
+				if game.playerObj != nil {
+					//Measured across the map's edges, since the player can warp over them
+					dist := level.Displacement(game.playerObj.pos, vmath.NewVec(t.centerX, t.centerY), true).Length()
+					if dist < minDist || dist > maxDist {
+						continue
+					}
+				}
 				//Skip tile if something's already there
 				for e := game.objects.Front(); e != nil; e = e.Next() {
 					obj := e.Value.(*Object)
@@ -177,7 +231,7 @@ func (level *Level) FindCenterSpawnPoint(game *Game) *Tile {
 // Like FindEmptySpace except for finding places inside of the walls
 func (level *Level) FindFullSpace(r int) *Tile {
 	for {
-		x, y := rand.Intn(level.cols), rand.Intn(level.rows)
+		x, y := level.rng.Intn(level.cols), level.rng.Intn(level.rows)
 		for j := y - r; j <= y+r; j++ {
 			for i := x - r; i <= x+r; i++ {
 				if !level.GetTile(i, j, true).IsSolid() {
@@ -306,6 +360,7 @@ func (level *Level) Draw(game *Game, screen *ebiten.Image, pt *ebiten.GeoM) {
 	if level.recalcEdges {
 		level.SmoothEdges()
 		level.recalcEdges = false
+		level.navVersion++
 	}
 
 	//Determine the area of the grid that is on screen
@@ -61,7 +61,7 @@ func AddLove(game *Game, count int, x, y float64) {
 		angle += rand.Float64() * math.Pi * 0.666
 		game.AddObject(&Object{
 			pos: vmath.NewVec(x, y), radius: 4.0, colType: CT_ITEM,
-			drawPriority: -1,
+			layer: RL_GROUND,
 			sprites: []*Sprite{
 				sprLoveBlink[0],
 			},
@@ -71,6 +71,14 @@ func AddLove(game *Game, count int, x, y float64) {
 }
 
 func (lv *Love) Update(game *Game, obj *Object) {
+	//Drift toward the player if they have a magnet
+	if player := game.playerObj.components[0].(*Player); player.powerUps.Active(PU_MAGNET) {
+		toPlayer := game.playerObj.pos.Clone().Sub(obj.pos)
+		if dist := toPlayer.Length(); dist < PU_MAGNET_RANGE && dist > 0.0 {
+			lv.velocity.Add(toPlayer.Scale(PU_MAGNET_STRENGTH * game.deltaTime / dist))
+			lv.life = max(lv.life, PICKUP_BLINK_TIME)
+		}
+	}
 	lv.Actor.Update(game, obj)
 
 	lv.blinkAnim.Update(game.deltaTime)
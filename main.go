@@ -18,6 +18,7 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
+	"flag"
 	"image"
 	_ "image/color"
 	_ "image/png"
@@ -47,6 +48,7 @@ type AppState interface {
 	Draw(screen *ebiten.Image)
 	Enter()
 	Leave()
+	Signals() *SignalScope //Subscriptions made by the state, which are cancelled when it is left
 }
 
 type App struct{}
@@ -91,6 +93,7 @@ func ChangeAppState(newState AppState) {
 	}
 	if __appState != nil {
 		__appState.Leave()
+		__appState.Signals().Release()
 	}
 	__appState = newState
 	newState.Enter()
@@ -113,6 +116,38 @@ func GetGraphics() *ebiten.Image {
 func main() {
 	//defer profile.Start(profile.ProfilePath(".")).Stop()
 
+	flag.StringVar(&__telemetryDir, "telemetry", "", "Write a log of gameplay events for each run to this directory")
+	flag.BoolVar(&__botPlaying, "bot", false, "Let the bot play the game")
+	botTrials := flag.Int("bot-trials", 0, "Have the bot play this many seeds of each mission without opening a window, and print the results. Ebiten still needs a display to start, so use xvfb-run on servers.")
+	botMission := flag.Int("bot-mission", -1, "Only run the bot trials on this mission")
+	botSeed := flag.Int64("bot-seed", 1, "First seed used for the bot trials")
+	botTime := flag.Float64("bot-time", 3.0, "Give up on a bot trial after this many times the mission's par time")
+	difficulty := flag.String("difficulty", "", "Play on this difficulty (gentle, normal or cruel) instead of the one last chosen on the title screen")
+	flag.Parse()
+
+	LoadDifficulty()
+	if *difficulty != "" {
+		diff, ok := ParseDifficulty(*difficulty)
+		if !ok {
+			log.Fatalf("There is no difficulty called %s.\n", *difficulty)
+		}
+		__difficulty = diff
+	}
+
+	if *botTrials > 0 {
+		missionNums := []int{*botMission}
+		if *botMission < 0 {
+			missionNums = make([]int, len(missions))
+			for i := range missionNums {
+				missionNums[i] = i
+			}
+		} else if *botMission >= len(missions) {
+			log.Fatalf("There is no mission %d.\n", *botMission)
+		}
+		RunBotTrials(missionNums, *botSeed, *botTrials, *botTime)
+		return
+	}
+
 	seed := time.Now().UnixNano() % 1615698000000000000
 	rand.Seed(seed)
 
@@ -21,15 +21,21 @@ import (
 	"image/color"
 )
 
+//Limits how many of an archetype may be on the playing field at once
+type SpawnCap struct {
+	archetype string
+	max       int
+}
+
 type Mission struct {
 	loveQuota           int
-	maxKnights          int
-	maxBlarghs          int
-	maxGopniks          int
-	maxWorms            int
-	maxBarrels          int
+	spawns              []SpawnCap //Archetypes that are spawned during the mission
+	pacing              []PacingPoint //Tuning curve for the spawn director. The default curve is used when empty
 	catHealth           int
+	catSkill            float64 //From 0 to 1. Determines how quickly the cat reacts to the player and whether it escapes across the edges of the map
 	knightSpeed			float64
+	powerUps            []PowerUpDrop //Chance for each power-up to be dropped by a killed monster
+	pylonPowerUps       int           //Number of power-ups placed next to pylons at the start
 	mapWidth, mapHeight int
 	bgColor1, bgColor2  color.RGBA
 	music               string
@@ -39,12 +45,28 @@ type Mission struct {
 
 var missions []Mission
 
+//The fight with the demon after the last mission. It's kept apart from the other missions so that it doesn't count toward the endings.
+//The love meter is the player's life here, so it starts out full.
+var bossMission = Mission{
+	loveQuota: 100,
+	spawns:    []SpawnCap{{"knight", 6}, {"blargh", 4}},
+	powerUps:  []PowerUpDrop{{PU_RAPID, 0.05}, {PU_SHIELD, 0.05}, {PU_SPREAD, 0.03}, {PU_LANCE, 0.02}, {PU_SEEKER, 0.02}, {PU_CHARGE, 0.02}},
+	pacing:    []PacingPoint{{0.0, 3.0, 2, 1.0, 5.0}, {1.0, 5.0, 1, 0.6, 8.0}},
+	mapWidth:  40, mapHeight: 40,
+	bgColor1: color.RGBA{186, 32, 32, 255},
+	bgColor2: color.RGBA{0, 0, 0, 255},
+	parTime:  (4 * 60),
+	music:    "him",
+}
+
 func init() {
 	missions = []Mission{
 		{ //Tutorial
 			loveQuota:  25,
-			maxKnights: 3,
+			spawns:     []SpawnCap{{"knight", 3}},
+			pacing:     []PacingPoint{{0.0, 5.0, 1, 0.4, 8.0}, {1.0, 5.0, 1, 0.6, 8.0}},
 			catHealth:  3,
+			catSkill: 0.0,
 			knightSpeed: 150.0,
 			mapWidth:   32, mapHeight: 32,
 			bgColor1: color.RGBA{91, 110, 225, 255},
@@ -54,9 +76,12 @@ func init() {
 		},
 		{ //1 (Cat)
 			loveQuota:  50,
-			maxKnights: 3, maxBlarghs: 3, maxBarrels: 6,
+			spawns:     []SpawnCap{{"knight", 3}, {"blargh", 3}, {"barrel", 6}},
 			catHealth: 3,
+			catSkill: 0.2,
 			knightSpeed: 150.0,
+			powerUps: []PowerUpDrop{{PU_SPREAD, 0.03}, {PU_RAPID, 0.03}},
+			pylonPowerUps: 1,
 			mapWidth:  32, mapHeight: 32,
 			bgColor1: color.RGBA{91, 110, 225, 255},
 			bgColor2: color.RGBA{48, 96, 130, 255},
@@ -65,9 +90,12 @@ func init() {
 		},
 		{ //2 (Human)
 			loveQuota:  75,
-			maxKnights: 15, maxBlarghs: 10, maxGopniks: 2, maxBarrels: 7,
+			spawns:     []SpawnCap{{"knight", 15}, {"blargh", 10}, {"gopnik", 2}, {"barrel", 7}},
 			catHealth: 6,
+			catSkill: 0.35,
 			knightSpeed: 175.0,
+			powerUps: []PowerUpDrop{{PU_SPREAD, 0.03}, {PU_RAPID, 0.03}, {PU_SPEED, 0.02}},
+			pylonPowerUps: 2,
 			mapWidth:  64, mapHeight: 64,
 			bgColor1: color.RGBA{48, 96, 130, 255},
 			bgColor2: color.RGBA{48, 96, 130, 255},
@@ -76,9 +104,12 @@ func init() {
 		},
 		{ //3 (Angel)
 			loveQuota:  75,
-			maxKnights: 15, maxBlarghs: 15, maxGopniks: 7, maxBarrels: 10,
+			spawns:     []SpawnCap{{"knight", 15}, {"blargh", 15}, {"gopnik", 7}, {"barrel", 10}},
 			catHealth: 8,
+			catSkill: 0.5,
 			knightSpeed: 175.0,
+			powerUps: []PowerUpDrop{{PU_SPREAD, 0.03}, {PU_RAPID, 0.03}, {PU_SPEED, 0.02}, {PU_MAGNET, 0.02}, {PU_LANCE, 0.01}},
+			pylonPowerUps: 2,
 			mapWidth:  48, mapHeight: 48,
 			bgColor1: color.RGBA{160, 0, 160, 255},
 			bgColor2: color.RGBA{160, 15, 160, 255},
@@ -87,9 +118,13 @@ func init() {
 		},
 		{ //4 (Corrupt)
 			loveQuota:  85,
-			maxKnights: 20, maxBlarghs: 20, maxGopniks: 16, maxBarrels: 15, maxWorms: 1,
+			spawns:     []SpawnCap{{"knight", 20}, {"blargh", 20}, {"gopnik", 16}, {"worm", 1}, {"barrel", 15}},
+			pacing:     []PacingPoint{{0.0, 4.0, 2, 0.7, 6.0}, {0.5, 3.0, 3, 0.9, 5.0}, {1.0, 3.0, 2, 1.0, 4.0}},
 			catHealth: 8,
+			catSkill: 0.65,
 			knightSpeed: 175.0,
+			powerUps: []PowerUpDrop{{PU_SPREAD, 0.03}, {PU_RAPID, 0.03}, {PU_SHIELD, 0.02}, {PU_SPEED, 0.02}, {PU_MAGNET, 0.02}, {PU_LANCE, 0.01}, {PU_SEEKER, 0.01}},
+			pylonPowerUps: 3,
 			mapWidth:  64, mapHeight: 64,
 			bgColor1: color.RGBA{34, 32, 32, 255},
 			bgColor2: color.RGBA{0, 0, 0, 255},
@@ -98,9 +133,13 @@ func init() {
 		},
 		{ //5 (Melting)
 			loveQuota:  100,
-			maxKnights: 25, maxBlarghs: 25, maxGopniks: 20, maxBarrels: 20, maxWorms: 5,
+			spawns:     []SpawnCap{{"knight", 25}, {"blargh", 25}, {"gopnik", 20}, {"worm", 5}, {"barrel", 20}},
+			pacing:     []PacingPoint{{0.0, 3.5, 2, 0.8, 5.0}, {0.5, 3.0, 3, 1.0, 4.0}, {1.0, 2.5, 3, 1.1, 4.0}},
 			catHealth: 10,
+			catSkill: 0.8,
 			knightSpeed: 175.0,
+			powerUps: []PowerUpDrop{{PU_SPREAD, 0.03}, {PU_RAPID, 0.03}, {PU_SHIELD, 0.03}, {PU_SPEED, 0.02}, {PU_MAGNET, 0.02}, {PU_LANCE, 0.01}, {PU_SEEKER, 0.01}, {PU_CHARGE, 0.01}},
+			pylonPowerUps: 3,
 			mapWidth:  72, mapHeight: 72,
 			bgColor1: color.RGBA{0, 0, 0, 255},
 			bgColor2: color.RGBA{0, 0, 0, 255},
@@ -109,9 +148,13 @@ func init() {
 		},
 		{ //6 (Monster)
 			loveQuota:  100,
-			maxKnights: 30, maxBlarghs: 30, maxGopniks: 25, maxBarrels: 30, maxWorms: 10,
+			spawns:     []SpawnCap{{"knight", 30}, {"blargh", 30}, {"gopnik", 25}, {"worm", 10}, {"barrel", 30}},
+			pacing:     []PacingPoint{{0.0, 3.0, 3, 0.9, 5.0}, {0.5, 2.5, 4, 1.1, 4.0}, {1.0, 2.0, 4, 1.2, 3.0}},
 			catHealth: 10,
+			catSkill: 1.0,
 			knightSpeed: 175.0,
+			powerUps: []PowerUpDrop{{PU_SPREAD, 0.04}, {PU_RAPID, 0.03}, {PU_SHIELD, 0.03}, {PU_SPEED, 0.02}, {PU_MAGNET, 0.02}, {PU_LANCE, 0.01}, {PU_SEEKER, 0.01}, {PU_CHARGE, 0.01}},
+			pylonPowerUps: 4,
 			mapWidth:  48, mapHeight: 72,
 			bgColor1: color.RGBA{0, 0, 0, 255},
 			bgColor2: color.RGBA{186, 32, 32, 255},
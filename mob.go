@@ -33,19 +33,30 @@ type Mob struct {
 	distToPlayer      float64
 	seesPlayer        bool
 	hunting           bool //Switched on after monster sees player for the first time
+	vision            VisionDef
+	steering          SteeringDef
+	perceiving        bool //Set once the mob is getting sight checks from the game's perception
+	ai                *StateMachine
+	heardPos          *vmath.Vec2f //Where the last noise the mob heard came from. Nil once it has been checked out.
+	path              *Path
+	pathTimer         float64 //Time until the path is found again
 }
 
+const (
+	PATH_REFRESH_TIME = 0.5 //Seconds between path searches for a mob following a path
+	FLOW_SHARE_DIST   = 2.0 //Mobs use the flow field instead of their own path when the place they're going is within this many tiles of the player
+	NOISE_SEARCH_TIME = 8.0 //Seconds a mob spends looking for the source of a noise before giving up
+)
+
 func (mb *Mob) Update(game *Game, obj *Object) {
 	mb.vecToPlayer = game.playerObj.pos.Clone().Sub(obj.pos)
 	mb.distToPlayer = mb.vecToPlayer.Length()
-	if raycast := game.level.Raycast(obj.pos.Clone(), mb.vecToPlayer, SCR_HEIGHT); raycast != nil {
-		if raycast.distance >= mb.vecToPlayer.Length() {
-			mb.lastSeenPlayerPos = game.playerObj.pos.Clone()
-			mb.seesPlayer = true
-			mb.hunting = true
-		} else {
-			mb.seesPlayer = false
-		}
+	//Line of sight is checked by the perception system every few updates
+	if !mb.perceiving {
+		game.perception.Add(obj, mb)
+	}
+	if mb.steering.Enabled() && !mb.dead {
+		game.flock.Add(obj, mb)
 	}
 
 	if mb.hurtTimer > 0.0 {
@@ -64,19 +75,30 @@ func (mb *Mob) Update(game *Game, obj *Object) {
 		mb.currAnim.Update(game.deltaTime)
 		obj.sprites[0] = mb.currAnim.GetSprite()
 	}
+	if mb.ai != nil {
+		mb.ai.Update(game, obj)
+	}
+}
+
+func (mb *Mob) StateMachine() *StateMachine {
+	return mb.ai
 }
 
 func (mb *Mob) OnCollision(game *Game, obj *Object, other *Object) {
-	if mb.hurtTimer <= 0.0 && other.HasColType(CT_PLAYERSHOT|CT_EXPLOSION) {
-		mb.health--
-		if other.HasColType(CT_EXPLOSION) {
-			mb.health -= 9
-		} else if other.HasColType(CT_BOUNCYSHOT) { //Bouncy shots do double damage
-			mb.health--
+	if mb.hurtTimer <= 0.0 && mb.health > 0 && other.HasColType(CT_PLAYERSHOT|CT_EXPLOSION) {
+		damage := 10
+		if !other.HasColType(CT_EXPLOSION) {
+			damage = ShotDamage(other, obj)
 		}
-		if mb.health > 0 {
-			mb.hurtTimer = 0.5
-			audio.PlaySound("enemy_hurt")
+		if damage > 0 {
+			mb.health -= damage
+			Emit_Signal(EnemyHurt{Enemy: obj, Archetype: obj.archetype, Source: other, Damage: damage})
+			if mb.health > 0 {
+				mb.hurtTimer = 0.5
+				audio.PlaySound("enemy_hurt")
+			} else {
+				Emit_Signal(EnemyKilled{Enemy: obj, Archetype: obj.archetype, Source: other, Pos: obj.pos.Clone()})
+			}
 		}
 	}
 	if other.colType == obj.colType {
@@ -97,3 +119,51 @@ func (mb *Mob) Wander(game *Game, obj *Object, rayDist, turnSpeed float64) {
 		mb.Turn(turnSpeed, game.deltaTime)
 	}
 }
+
+//Returns the direction to move in to reach the goal without running into walls.
+//Returns a zero vector once the goal is reached or if there is no way to get there.
+func (mb *Mob) NavigateTo(game *Game, obj *Object, goal *vmath.Vec2f) *vmath.Vec2f {
+	mb.pathTimer -= game.deltaTime
+	if mb.path == nil || mb.pathTimer <= 0.0 || mb.path.Goal().Clone().Sub(goal).Length() > TILE_SIZE {
+		mb.pathTimer = PATH_REFRESH_TIME
+		mb.path = game.level.FindPath(obj.pos, goal, obj.radius, false)
+		if mb.path == nil {
+			return vmath.ZeroVec()
+		}
+	}
+	if dir := mb.path.Steer(game.level, obj.pos, obj.radius); dir != nil {
+		return dir
+	}
+	return vmath.ZeroVec()
+}
+
+//Returns the direction to move in to go after the player. If the player can't be seen, it leads to where they were last seen.
+func (mb *Mob) ChaseDirection(game *Game, obj *Object) *vmath.Vec2f {
+	if mb.seesPlayer {
+		mb.path = nil
+		return mb.lastSeenPlayerPos.Clone().Sub(obj.pos)
+	}
+	//The shared flow field leads to the same place without a search of our own if the player hasn't gone far since
+	if game.playerField.Distance(mb.lastSeenPlayerPos) <= FLOW_SHARE_DIST {
+		mb.path = nil
+		return mb.FlowDirection(game, obj)
+	}
+	return mb.NavigateTo(game, obj, mb.lastSeenPlayerPos)
+}
+
+//Returns the direction to move in to reach the player by following the game's shared flow field
+func (mb *Mob) FlowDirection(game *Game, obj *Object) *vmath.Vec2f {
+	return game.playerField.Direction(obj.pos)
+}
+
+//Returns the direction to move in to check out the last noise the mob heard. The noise is forgotten once the mob gets there.
+func (mb *Mob) Investigate(game *Game, obj *Object) *vmath.Vec2f {
+	if mb.heardPos == nil {
+		return vmath.ZeroVec()
+	}
+	dir := mb.NavigateTo(game, obj, mb.heardPos)
+	if dir.X == 0.0 && dir.Y == 0.0 {
+		mb.heardPos = nil
+	}
+	return dir
+}
@@ -36,6 +36,7 @@ const (
 	CT_CAT        ColType = 1 << 7
 	CT_EXPLOSION  ColType = 1 << 8
 	CT_BARREL     ColType = 1 << 9
+	CT_PICKUP     ColType = 1 << 10
 )
 
 type Component interface {
@@ -48,14 +49,18 @@ type Collidable interface {
 
 //Object ...
 type Object struct {
-	pos          *vmath.Vec2f
-	radius       float64
-	colType      ColType
-	sprites      []*Sprite
-	components   []Component
-	drawPriority int
-	removeMe     bool
-	hidden       bool
+	pos        *vmath.Vec2f
+	radius     float64
+	colType    ColType
+	sprites    []*Sprite
+	components []Component
+	layer      RenderLayer
+	removeMe   bool
+	hidden     bool
+	archetype  string //Name of the archetype the object was spawned from, if any
+	parent     *Object
+	children   []*Object
+	localPos   *vmath.Vec2f //Offset from the parent's position. The world position is derived from this while the object has a parent.
 }
 
 func (obj *Object) Intersects(other *Object) bool {
@@ -66,38 +71,73 @@ func (obj *Object) HasColType(target ColType) bool {
 	return (obj.colType & target) > 0
 }
 
+//Draws the object and its children. Children are placed relative to their parent.
 func (obj *Object) DrawAllSprites(screen *ebiten.Image, pt *ebiten.GeoM) {
 	var objT ebiten.GeoM
 	if pt != nil {
 		objT = *pt
 	}
 	objT.Translate(obj.pos.X, obj.pos.Y)
+	obj.drawWithChildren(screen, &objT)
+}
+
+func (obj *Object) drawWithChildren(screen *ebiten.Image, objT *ebiten.GeoM) {
+	if obj.hidden {
+		return
+	}
 	for _, sp := range obj.sprites {
-		sp.Draw(screen, &objT)
+		sp.Draw(screen, objT)
+	}
+	for _, child := range obj.children {
+		childT := *objT
+		childT.Translate(child.localPos.X, child.localPos.Y)
+		child.drawWithChildren(screen, &childT)
 	}
 }
 
-//Helper for allowing objects to keep track of how many of them are on the playing field
-type ObjCtr struct {
-	count int
+//Attaches the child to this object at the given offset. The child is detached from its previous parent, if any.
+func (obj *Object) AttachChild(child *Object, localX, localY float64) *Object {
+	child.Detach()
+	child.parent = obj
+	child.localPos = vmath.NewVec(localX, localY)
+	child.pos = obj.pos.Clone().Add(child.localPos)
+	obj.children = append(obj.children, child)
+	return child
 }
 
-func (ctr *ObjCtr) HandleSignal(kind Signal, src interface{}, params map[string]interface{}) {
-	if kind == SIGNAL_GAME_INIT {
-		ctr.count = 0
+//Removes the object from its parent's children. It stays where it is in the world.
+func (obj *Object) Detach() {
+	if obj.parent == nil {
+		return
 	}
+	for i, c := range obj.parent.children {
+		if c == obj {
+			obj.parent.children = append(obj.parent.children[:i], obj.parent.children[i+1:]...)
+			break
+		}
+	}
+	obj.parent = nil
+	obj.localPos = nil
 }
 
-func NewObjCtr() *ObjCtr {
-	ctr := &ObjCtr{}
-	Listen_Signal(SIGNAL_GAME_INIT, ctr)
-	return ctr
-}
-
-func (ctr *ObjCtr) Inc() {
-	ctr.count++
+//Returns true if the object or any of its ancestors are hidden
+func (obj *Object) IsHidden() bool {
+	for o := obj; o != nil; o = o.parent {
+		if o.hidden {
+			return true
+		}
+	}
+	return false
 }
 
-func (ctr *ObjCtr) Dec() {
-	ctr.count--
+//Moves children along with the object and flags them for removal along with it
+func (obj *Object) UpdateChildren() {
+	for _, child := range obj.children {
+		if obj.removeMe {
+			child.removeMe = true
+		}
+		child.pos.X = obj.pos.X + child.localPos.X
+		child.pos.Y = obj.pos.Y + child.localPos.Y
+		child.UpdateChildren()
+	}
 }
@@ -0,0 +1,296 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+const (
+	PATH_MAX_NODES   = 4096        //Number of tiles the search looks at before giving up
+	PATH_CACHE_SIZE  = 256         //Number of paths remembered before the cache is cleared out
+	PATH_SMOOTH_STEP = TILE_SIZE_H //Distance between the points checked when testing if a shortcut is clear
+)
+
+//A route through the level as a list of points in pixel coordinates
+type Path struct {
+	points []*vmath.Vec2f
+	index  int  //Point currently being headed for
+	wrap   bool //True if the path is allowed to cross the edges of the map
+}
+
+func (p *Path) Done() bool {
+	return p.index >= len(p.points)
+}
+
+//Returns the final point of the path
+func (p *Path) Goal() *vmath.Vec2f {
+	if len(p.points) == 0 {
+		return nil
+	}
+	return p.points[len(p.points)-1]
+}
+
+//Returns the offset from pos to the point that should be headed for next, skipping points that are within reach.
+//Returns nil once the end of the path has been reached.
+func (p *Path) Steer(level *Level, pos *vmath.Vec2f, reach float64) *vmath.Vec2f {
+	for ; p.index < len(p.points); p.index++ {
+		diff := level.Displacement(pos, p.points[p.index], p.wrap)
+		if diff.Length() > reach {
+			return diff
+		}
+	}
+	return nil
+}
+
+//Returns the shortest offset from one point to another. If wrap is set, the offset may cross the edges of the map.
+func (level *Level) Displacement(from, to *vmath.Vec2f, wrap bool) *vmath.Vec2f {
+	diff := to.Clone().Sub(from)
+	if wrap {
+		if diff.X > level.pixelWidth/2.0 {
+			diff.X -= level.pixelWidth
+		} else if diff.X < -level.pixelWidth/2.0 {
+			diff.X += level.pixelWidth
+		}
+		if diff.Y > level.pixelHeight/2.0 {
+			diff.Y -= level.pixelHeight
+		} else if diff.Y < -level.pixelHeight/2.0 {
+			diff.Y += level.pixelHeight
+		}
+	}
+	return diff
+}
+
+//Returns the longest distance there can be between two points when measuring across the map's edges
+func (level *Level) MaxWrappedDistance() float64 {
+	return math.Hypot(level.pixelWidth/2.0, level.pixelHeight/2.0)
+}
+
+type pathKey struct {
+	startX, startY int
+	goalX, goalY   int
+	radius         int
+	wrap           bool
+}
+
+//Tile visited by the search
+type pathNode struct {
+	x, y   int
+	cost   float64 //Cost of the cheapest known route from the start
+	score  float64 //Cost plus estimated cost to the goal
+	parent *pathNode
+	index  int //Position in the open heap, or -1 if it has been taken out
+}
+
+type pathHeap []*pathNode
+
+func (h pathHeap) Len() int           { return len(h) }
+func (h pathHeap) Less(i, j int) bool { return h[i].score < h[j].score }
+func (h pathHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *pathHeap) Push(x interface{}) {
+	node := x.(*pathNode)
+	node.index = len(*h)
+	*h = append(*h, node)
+}
+func (h *pathHeap) Pop() interface{} {
+	old := *h
+	node := old[len(old)-1]
+	node.index = -1
+	*h = old[:len(old)-1]
+	return node
+}
+
+//Neighboring tile offsets, straight directions first
+var pathDirs = [8][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+
+//Finds a way from start to goal around solid tiles for something of the given radius.
+//If wrap is set, the path may cross the edges of the map, but whatever follows it has to warp across by itself.
+//Returns nil if there is no way to get there.
+func (level *Level) FindPath(start, goal *vmath.Vec2f, radius float64, wrap bool) *Path {
+	sx, sy := int(start.X/TILE_SIZE), int(start.Y/TILE_SIZE)
+	gx, gy := int(goal.X/TILE_SIZE), int(goal.Y/TILE_SIZE)
+	if wrap {
+		sx, sy = level.WrapGridCoords(sx, sy)
+		gx, gy = level.WrapGridCoords(gx, gy)
+	}
+	if !level.walkable(sx, sy, wrap) || !level.walkable(gx, gy, wrap) {
+		return nil
+	}
+
+	//Paths are forgotten whenever the terrain changes
+	if level.pathCache == nil || level.pathCacheVersion != level.navVersion || len(level.pathCache) > PATH_CACHE_SIZE {
+		level.pathCache = make(map[pathKey][]*vmath.Vec2f)
+		level.pathCacheVersion = level.navVersion
+	}
+	key := pathKey{sx, sy, gx, gy, int(math.Ceil(radius)), wrap}
+	points, ok := level.pathCache[key]
+	if !ok {
+		points = level.searchPath(sx, sy, gx, gy, wrap)
+		if points != nil {
+			points = level.smoothPath(points, radius, wrap)
+		}
+		level.pathCache[key] = points
+	}
+	if points == nil {
+		return nil
+	}
+	//End exactly at the goal instead of the center of its tile
+	route := make([]*vmath.Vec2f, 0, len(points)+1)
+	if len(points) > 0 {
+		route = append(route, points[:len(points)-1]...)
+	}
+	route = append(route, goal.Clone())
+	return &Path{points: route, wrap: wrap}
+}
+
+//Returns true if the tile can be walked through
+func (level *Level) walkable(x, y int, wrap bool) bool {
+	t := level.GetTile(x, y, wrap)
+	return t != nil && !t.IsSolid()
+}
+
+//Returns true if a diagonal move can squeeze past the tile at x, y on its way around the corner at cx, cy.
+//Slopes only get in the way if their solid side faces the corner.
+func (level *Level) cornerPassable(x, y int, cx, cy float64, wrap bool) bool {
+	t := level.GetTile(x, y, wrap)
+	if t == nil {
+		return false
+	}
+	if !t.IsSolid() {
+		return true
+	}
+	if t.IsSlope() {
+		toCorner := vmath.NewVec(cx-t.centerX, cy-t.centerY).Normalize()
+		return vmath.VecDot(t.GetSlopeNormal(), toCorner) > 0.5
+	}
+	return false
+}
+
+//A* search over the tile grid. Returns the centers of the tiles along the way, not including the start.
+func (level *Level) searchPath(sx, sy, gx, gy int, wrap bool) []*vmath.Vec2f {
+	//Estimated cost between tiles, allowing diagonal moves
+	estimate := func(x, y int) float64 {
+		dx, dy := math.Abs(float64(gx-x)), math.Abs(float64(gy-y))
+		if wrap {
+			dx = math.Min(dx, float64(level.cols)-dx)
+			dy = math.Min(dy, float64(level.rows)-dy)
+		}
+		return math.Max(dx, dy) + (math.Sqrt2-1.0)*math.Min(dx, dy)
+	}
+
+	nodes := make(map[int]*pathNode)
+	open := &pathHeap{}
+	first := &pathNode{x: sx, y: sy, score: estimate(sx, sy)}
+	nodes[sy*level.cols+sx] = first
+	heap.Push(open, first)
+
+	for expanded := 0; open.Len() > 0 && expanded < PATH_MAX_NODES; expanded++ {
+		node := heap.Pop(open).(*pathNode)
+		if node.x == gx && node.y == gy {
+			points := make([]*vmath.Vec2f, 0, int(node.cost)+1)
+			for n := node; n.parent != nil; n = n.parent {
+				points = append(points, vmath.NewVec(float64(n.x)*TILE_SIZE+TILE_SIZE_H, float64(n.y)*TILE_SIZE+TILE_SIZE_H))
+			}
+			//Reverse so that it goes from start to goal
+			for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+				points[i], points[j] = points[j], points[i]
+			}
+			return points
+		}
+
+		for _, dir := range pathDirs {
+			nx, ny := node.x+dir[0], node.y+dir[1]
+			if wrap {
+				nx, ny = level.WrapGridCoords(nx, ny)
+			}
+			if !level.walkable(nx, ny, wrap) {
+				continue
+			}
+			stepCost := 1.0
+			if dir[0] != 0 && dir[1] != 0 {
+				//Don't cut through the corners of walls
+				cx := float64(node.x)*TILE_SIZE + TILE_SIZE_H + float64(dir[0])*TILE_SIZE_H
+				cy := float64(node.y)*TILE_SIZE + TILE_SIZE_H + float64(dir[1])*TILE_SIZE_H
+				if !level.cornerPassable(node.x+dir[0], node.y, cx, cy, wrap) || !level.cornerPassable(node.x, node.y+dir[1], cx, cy, wrap) {
+					continue
+				}
+				stepCost = math.Sqrt2
+			}
+
+			cost := node.cost + stepCost
+			id := ny*level.cols + nx
+			next, seen := nodes[id]
+			if !seen {
+				next = &pathNode{x: nx, y: ny, cost: cost, score: cost + estimate(nx, ny), parent: node}
+				nodes[id] = next
+				heap.Push(open, next)
+			} else if cost < next.cost && next.index >= 0 {
+				next.cost = cost
+				next.score = cost + estimate(nx, ny)
+				next.parent = node
+				heap.Fix(open, next.index)
+			}
+		}
+	}
+	return nil
+}
+
+//Removes the points that can be skipped by walking in a straight line
+func (level *Level) smoothPath(points []*vmath.Vec2f, radius float64, wrap bool) []*vmath.Vec2f {
+	if len(points) < 3 {
+		return points
+	}
+	smooth := []*vmath.Vec2f{points[0]}
+	for i := 0; i < len(points)-1; {
+		//Find the furthest point that can be seen from this one
+		j := len(points) - 1
+		for ; j > i+1; j-- {
+			if level.clearLine(points[i], points[j], radius, wrap) {
+				break
+			}
+		}
+		smooth = append(smooth, points[j])
+		i = j
+	}
+	return smooth
+}
+
+//Returns true if something of the given radius can move in a straight line between the points without hitting a wall
+func (level *Level) clearLine(from, to *vmath.Vec2f, radius float64, wrap bool) bool {
+	diff := to.Clone().Sub(from)
+	dist := diff.Length()
+	//Lines across the edges of the map can't be walked in one go
+	if wrap && level.Displacement(from, to, true).Length() < dist {
+		return false
+	}
+	steps := int(math.Ceil(dist / PATH_SMOOTH_STEP))
+	for s := 1; s < steps; s++ {
+		pos := from.Clone().Add(diff.Clone().Scale(float64(s) / float64(steps)))
+		if hit, _, _ := level.SphereIntersects(pos, radius); hit {
+			return false
+		}
+	}
+	return true
+}
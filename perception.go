@@ -0,0 +1,181 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+const (
+	PERCEPTION_BUDGET          = 8  //Maximum number of sight checks per update
+	PERCEPTION_CACHE_TICKS     = 4  //Number of updates a mob keeps the result of a sight check before it can look again
+	PERCEPTION_OFFSCREEN_TICKS = 15 //Same as above, for mobs that are off screen
+	DEFAULT_VISION_RANGE       = SCR_HEIGHT
+	DEFAULT_VISION_CONE        = 360.0
+)
+
+type perceiver struct {
+	obj      *Object
+	mob      *Mob
+	age      int  //Updates since the mob last looked for the player
+	onScreen bool //Updated at the start of each update
+}
+
+//Spreads out the mobs' line of sight checks over several updates, taking turns so that each mob gets to look every so often.
+//Mobs on screen go first, since the player can see them react.
+type Perception struct {
+	perceivers []*perceiver
+	urgent     []*perceiver //Mobs on screen that are due to look, reused between updates
+	cursor     int          //Index of the next mob to get a turn
+	checks     int          //Number of checks made during the last update
+}
+
+func NewPerception() *Perception {
+	return &Perception{
+		perceivers: make([]*perceiver, 0, 64),
+	}
+}
+
+//Starts scheduling sight checks for the mob
+func (pc *Perception) Add(obj *Object, mob *Mob) {
+	mob.perceiving = true
+	//Look right away so that the mob doesn't start out blind
+	pc.perceivers = append(pc.perceivers, &perceiver{obj: obj, mob: mob, age: PERCEPTION_OFFSCREEN_TICKS})
+}
+
+func (pc *Perception) Update(game *Game) {
+	//Forget mobs that are gone
+	alive := pc.perceivers[:0]
+	for _, p := range pc.perceivers {
+		if !p.obj.removeMe && !p.mob.dead {
+			p.age++
+			alive = append(alive, p)
+		}
+	}
+	for i := len(alive); i < len(pc.perceivers); i++ {
+		pc.perceivers[i] = nil
+	}
+	pc.perceivers = alive
+
+	pc.checks = 0
+	//Mobs on screen that have waited the longest look first
+	pc.urgent = pc.urgent[:0]
+	for _, p := range pc.perceivers {
+		p.onScreen = game.SquareOnScreen(p.obj.pos.X, p.obj.pos.Y, p.obj.radius)
+		if p.onScreen && p.age >= PERCEPTION_CACHE_TICKS {
+			pc.urgent = append(pc.urgent, p)
+		}
+	}
+	sort.SliceStable(pc.urgent, func(i, j int) bool { return pc.urgent[i].age > pc.urgent[j].age })
+	for _, p := range pc.urgent {
+		if pc.checks >= PERCEPTION_BUDGET {
+			break
+		}
+		pc.look(game, p)
+	}
+	//Whatever is left of the budget goes to everyone else, taking turns
+	for visited := 0; visited < len(pc.perceivers) && pc.checks < PERCEPTION_BUDGET; visited++ {
+		pc.cursor = pc.cursor % len(pc.perceivers)
+		p := pc.perceivers[pc.cursor]
+		pc.cursor++
+		minAge := PERCEPTION_CACHE_TICKS
+		if !p.onScreen {
+			minAge = PERCEPTION_OFFSCREEN_TICKS
+		}
+		if p.age >= minAge {
+			pc.look(game, p)
+		}
+	}
+}
+
+func (pc *Perception) look(game *Game, p *perceiver) {
+	p.mob.Look(game, p.obj)
+	p.age = 0
+	pc.checks++
+}
+
+func (pc *Perception) DebugString() string {
+	return fmt.Sprintf("SEE %d/%d", pc.checks, len(pc.perceivers))
+}
+
+//Checks if the player is within the mob's field of view and not hidden behind walls
+func (mb *Mob) Look(game *Game, obj *Object) {
+	toPlayer := game.playerObj.pos.Clone().Sub(obj.pos)
+	dist := toPlayer.Length()
+	mb.seesPlayer = false
+
+	visRange, visCone := mb.vision.Range, mb.vision.Cone
+	if visRange <= 0.0 {
+		visRange = DEFAULT_VISION_RANGE
+	}
+	if visCone <= 0.0 {
+		visCone = DEFAULT_VISION_CONE
+	}
+	if dist > visRange {
+		return
+	}
+	//The cone only matters for noticing the player. Once a mob is hunting, it keeps track of them from any angle.
+	if !mb.hunting && visCone < 360.0 && dist > 0.0 {
+		cos := (toPlayer.X*mb.facing.X + toPlayer.Y*mb.facing.Y) / (dist * mb.facing.Length())
+		if cos < math.Cos(visCone*math.Pi/360.0) {
+			return
+		}
+	}
+	if raycast := game.level.Raycast(obj.pos.Clone(), toPlayer, visRange); raycast.distance >= dist {
+		mb.lastSeenPlayerPos = game.playerObj.pos.Clone()
+		mb.seesPlayer = true
+		mb.hunting = true
+	}
+}
+
+//Loudness of noises
+const (
+	NOISE_SHOT         = 160.0
+	NOISE_EXPLOSION    = 320.0
+	NOISE_WALL_DAMPING = 0.5 //Multiplies the loudness of noises that have to go through walls
+)
+
+//Lets monsters around the position know that something happened there
+func MakeNoise(pos *vmath.Vec2f, loudness float64, source *Object) {
+	Emit_Signal(NoiseMade{Pos: pos.Clone(), Loudness: loudness, Source: source})
+}
+
+//Alerts the mobs that are close enough to hear the noise
+func (pc *Perception) Hear(game *Game, ev NoiseMade) {
+	for _, p := range pc.perceivers {
+		if p.obj.removeMe || p.mob.dead || p.obj == ev.Source {
+			continue
+		}
+		toNoise := ev.Pos.Clone().Sub(p.obj.pos)
+		dist := toNoise.Length()
+		if dist > ev.Loudness {
+			continue
+		}
+		//Walls muffle the noise. The ray is only cast for mobs that might not be able to hear it through them.
+		if dist > ev.Loudness*NOISE_WALL_DAMPING {
+			if raycast := game.level.Raycast(p.obj.pos.Clone(), toNoise, dist); raycast.hit && raycast.distance < dist {
+				continue
+			}
+		}
+		p.mob.heardPos = ev.Pos.Clone()
+	}
+}
@@ -20,23 +20,23 @@ package main
 import (
 	"image"
 
-	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/thetophatdemon/feta-feles-rebirth/audio"
 	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
 )
 
 const (
-	PL_SHOOT_FREQ = 0.2
 	PL_WARP_THRESHOLD = 1.0
 )
 
 type Player struct {
 	*Actor
 	hurt, ascended   bool
-	shootTimer       float64
+	trigger Trigger
 	hurtTimer        float64
 	lastShootDir     *vmath.Vec2f
 	warpCooldown float64
+	input InputSource
+	powerUps PowerUps
 }
 
 var plSpriteNormal *Sprite
@@ -57,6 +57,7 @@ func AddPlayer(game *Game, x, y float64) *Object {
 		hurt:         false,
 		ascended:     false,
 		lastShootDir: vmath.NewVec(1.0, 0.0),
+		input:        &KeyboardInput{},
 	}
 	//player.Actor.ignoreBounds = true
 	obj := &Object{
@@ -64,8 +65,8 @@ func AddPlayer(game *Game, x, y float64) *Object {
 		sprites: []*Sprite{
 			plSpriteNormal,
 		},
-		components:   []Component{player},
-		drawPriority: 10,
+		components: []Component{player},
+		layer:      RL_PLAYER,
 	}
 	game.AddObject(obj)
 
@@ -73,39 +74,33 @@ func AddPlayer(game *Game, x, y float64) *Object {
 }
 
 func (player *Player) Update(game *Game, obj *Object) {
+	in := player.input.PlayerInput(game, obj)
+
 	//Attack
-	if player.shootTimer <= 0.0 {
-		//Set direction
-		var dir *vmath.Vec2f
-		if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) { //Shoot in direction of mouse click
-			cx, cy := ebiten.CursorPosition()
-			rPos := obj.pos.Clone().Sub(game.camPos).Add(vmath.NewVec(SCR_WIDTH_H, SCR_HEIGHT_H))
-			dir = (vmath.NewVec(float64(cx), float64(cy))).Sub(rPos)
-			player.lastShootDir = dir
-		} else if ebiten.IsKeyPressed(ebiten.KeySpace) { //Or shoot in direction of last movement
-			if player.lastShootDir == nil {
-				player.lastShootDir = player.facing.Clone()
-			}
-			dir = player.lastShootDir
-		} else {
-			player.lastShootDir = nil
-		}
-		//Add shot
-		if dir != nil {
-			if player.ascended {
-				AddBouncyShot(game, obj.pos, dir, 240.0, false, 2)
-				player.shootTimer = PL_SHOOT_FREQ / 2.0
-			} else {
-				AddShot(game, obj.pos, dir, 240.0, false)
-				player.shootTimer = PL_SHOOT_FREQ
-			}
-			audio.PlaySound("player_shot")
-			Emit_Signal(SIGNAL_PLAYER_SHOT, obj, nil)
+	var dir *vmath.Vec2f
+	if in.Aim != nil {
+		dir = in.Aim.Clone()
+		player.lastShootDir = dir
+	} else if in.Fire {
+		if player.lastShootDir == nil {
+			player.lastShootDir = player.facing.Clone()
 		}
+		dir = player.lastShootDir
 	} else {
-		player.shootTimer -= game.deltaTime
+		player.lastShootDir = nil
+	}
+	wpn, mods := player.Weapon(), player.powerUps.WeaponMods()
+	if player.trigger.Update(game, obj, wpn, mods, dir) {
+		Emit_Signal(PlayerShot{Player: obj, Dir: player.trigger.aim.Clone(), Projectiles: wpn.Projectiles(mods)})
+		MakeNoise(obj.pos, NOISE_SHOT, obj)
 	}
 
+	player.powerUps.Update(game.deltaTime, func(kind PowerUpType) {
+		if kind == PU_SPEED {
+			player.maxSpeed /= PU_SPEED_MULT
+		}
+	})
+
 	if player.hurtTimer > 0.0 {
 		player.hurtTimer -= game.deltaTime
 		if int(player.hurtTimer/0.125)%2 == 0 {
@@ -126,7 +121,7 @@ func (player *Player) Update(game *Game, obj *Object) {
 		if player.ascended {
 			obj.sprites[0] = plSpriteAscended
 		} else {
-			if player.shootTimer > 0.0 {
+			if player.trigger.Busy() {
 				obj.sprites[0] = plSpriteShoot
 			} else {
 				obj.sprites[0] = plSpriteNormal
@@ -136,55 +131,25 @@ func (player *Player) Update(game *Game, obj *Object) {
 
 	//Movement
 	var dx, dy float64
-	if ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
-		dy = -1.0
-	} else if ebiten.IsKeyPressed(ebiten.KeyDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
-		dy = 1.0
-	}
-
-	if ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
-		dx = 1.0
-	} else if ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
-		dx = -1.0
+	if in.Move != nil {
+		dx, dy = in.Move.X, in.Move.Y
 	}
 
 	if dx != 0.0 || dy != 0.0 {
-		Emit_Signal(SIGNAL_PLAYER_MOVED, obj, nil)
+		Emit_Signal(PlayerMoved{Player: obj})
 	}
 
 	//Handle boundaries & screen wrapping
-	leftWarp := (obj.pos.X <= obj.radius + 4 && dx < 0)
-	rightWarp := (obj.pos.X >= game.level.pixelWidth - obj.radius - 4 && dx > 0)
-	upWarp := (obj.pos.Y <= obj.radius + 4 && dy < 0)
-	downWarp := (obj.pos.Y >= game.level.pixelHeight - obj.radius - 4 && dy > 0)
-	if leftWarp || rightWarp || upWarp || downWarp {
+	if game.level.AtWarpEdge(obj.pos, obj.radius, dx, dy) {
 		player.warpCooldown += game.deltaTime
+		//Warp after pushing against boundary for some time
 		if player.warpCooldown > PL_WARP_THRESHOLD {
-			//Warp after pushing against boundary for some time
-			switch {
-			case leftWarp:
-				if hit, _, _ := game.level.SphereIntersects(vmath.NewVec(game.level.pixelWidth-obj.radius - 1, obj.pos.Y), obj.radius); !hit {
-					obj.pos.X = game.level.pixelWidth - obj.radius
-					player.warpCooldown = 0.0
-				}
-			case rightWarp:
-				if hit, _, _ := game.level.SphereIntersects(vmath.NewVec(obj.radius + 1, obj.pos.Y), obj.radius); !hit {
-					obj.pos.X = obj.radius
-					player.warpCooldown = 0.0
-				}
-			case upWarp:
-				if hit, _, _ := game.level.SphereIntersects(vmath.NewVec(obj.pos.X, game.level.pixelHeight-obj.radius-1), obj.radius); !hit {
-					obj.pos.Y = game.level.pixelHeight - obj.radius
-					player.warpCooldown = 0.0
-				}
-			case downWarp:
-				if hit, _, _ := game.level.SphereIntersects(vmath.NewVec(obj.pos.X, obj.radius+1), obj.radius); !hit {
-					obj.pos.Y = obj.radius
-					player.warpCooldown = 0.0
-				}
-			}
-			if player.warpCooldown == 0.0 {
+			if dest := game.level.WarpDestination(obj.pos, obj.radius, dx, dy); dest != nil {
+				from := obj.pos.Clone()
+				obj.pos.X, obj.pos.Y = dest.X, dest.Y
+				player.warpCooldown = 0.0
 				player.hurtTimer = 1.0 //Add invincibility frames after warping in case there's an unseen enemy
+				Emit_Signal(PlayerWarped{Player: obj, From: from, To: obj.pos.Clone()})
 			}
 		}
 	} else {
@@ -195,29 +160,57 @@ func (player *Player) Update(game *Game, obj *Object) {
 	player.Actor.Update(game, obj)
 }
 
+//Returns the weapon that the player is using. Power-ups can switch it, and ascension turns it into a stronger one.
+func (player *Player) Weapon() *Weapon {
+	name := PL_WEAPON
+	if pu := player.powerUps.Weapon(); pu != "" {
+		name = pu
+	}
+	wpn := weapons[name]
+	if player.ascended && wpn.ascended != "" {
+		wpn = weapons[wpn.ascended]
+	}
+	return wpn
+}
+
 func (player *Player) OnCollision(game *Game, obj, other *Object) {
 	switch {
 	case other.HasColType(CT_ITEM):
 		ascend := game.IncLoveCounter(1)
 		if ascend {
 			if !player.ascended {
-				Emit_Signal(SIGNAL_PLAYER_ASCEND, obj, nil)
+				Emit_Signal(PlayerAscended{Player: obj})
 			}
 			player.ascended = true
 		}
+	case other.HasColType(CT_PICKUP):
+		if other.removeMe {
+			break //Collected last tick, but not removed until the end of this one
+		}
+		kind := other.components[0].(*Pickup).kind
+		if player.powerUps.Grant(kind) && kind == PU_SPEED {
+			player.maxSpeed *= PU_SPEED_MULT
+		}
 	case other.HasColType(CT_ENEMY | CT_ENEMYSHOT | CT_EXPLOSION):
-		if !player.hurt && player.hurtTimer <= 0.0 {
+		if !player.hurt && player.hurtTimer <= 0.0 && player.powerUps.Active(PU_SHIELD) {
+			//The shield takes the hit instead
+			player.powerUps.Spend(PU_SHIELD)
+			player.hurtTimer = 1.0
+			audio.PlaySound("enemy_hurt")
+		} else if !player.hurt && player.hurtTimer <= 0.0 {
 			player.hurt = true
 			player.hurtTimer = 1.0
-			lost := false
+			damage := 10
 			if other.colType == CT_EXPLOSION {
-				lost = game.DecLoveCounter(20)
-			} else {
-				lost = game.DecLoveCounter(10)
+				damage = 20
 			}
+			damage = game.difficulty.HurtDamage(damage)
+			Emit_Signal(PlayerHurt{Player: obj, Source: other, Damage: damage, Pos: obj.pos.Clone()})
+			lost := game.DecLoveCounter(damage)
 			if lost && player.ascended {
 				player.ascended = false
 				audio.PlaySound("descend")
+				Emit_Signal(PlayerDescended{Player: obj})
 			} else {
 				audio.PlaySound("player_hurt")
 			}
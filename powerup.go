@@ -0,0 +1,282 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"math/rand"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/audio"
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+type PowerUpType int
+
+const (
+	PU_SPREAD PowerUpType = iota //Fires extra shots off to the sides
+	PU_RAPID                     //Shoots twice as often
+	PU_SHIELD                    //Blocks hits
+	PU_SPEED                     //Moves faster
+	PU_MAGNET                    //Pulls in love from a distance
+	PU_LANCE                     //Switches to shots that pierce through enemies
+	PU_SEEKER                    //Switches to shots that home in on enemies
+	PU_CHARGE                    //Switches to shots that get stronger the longer the trigger is held
+	PU_COUNT
+)
+
+//What happens when a power-up is picked up while it's still active
+type StackRule int
+
+const (
+	STACK_REFRESH StackRule = iota //The timer starts over
+	STACK_EXTEND                   //The duration is added to the time left, up to the limit
+	STACK_LEVEL                    //It gets one level stronger, up to the limit, and the timer starts over
+)
+
+type PowerUpDef struct {
+	name     string //Shown on the HUD. Six letters at most.
+	glyph    rune   //Letter drawn on the pickup
+	duration float64
+	stack    StackRule
+	limit    float64 //Maximum time left for STACK_EXTEND, or maximum level for STACK_LEVEL
+	weapon   string  //Weapon used while it's active. Only one weapon power-up can be active at a time.
+}
+
+var powerUpDefs = [PU_COUNT]PowerUpDef{
+	PU_SPREAD: {name: "SPREAD", glyph: 'W', duration: 10.0, stack: STACK_LEVEL, limit: 3},
+	PU_RAPID:  {name: "RAPID", glyph: 'R', duration: 8.0, stack: STACK_EXTEND, limit: 20.0},
+	PU_SHIELD: {name: "SHIELD", glyph: 'S', duration: 20.0, stack: STACK_LEVEL, limit: 3}, //Each level blocks one hit
+	PU_SPEED:  {name: "SPEED", glyph: 'F', duration: 8.0, stack: STACK_REFRESH},
+	PU_MAGNET: {name: "MAGNET", glyph: 'M', duration: 12.0, stack: STACK_REFRESH},
+	PU_LANCE:  {name: "LANCE", glyph: 'L', duration: 15.0, stack: STACK_REFRESH, weapon: "lance"},
+	PU_SEEKER: {name: "SEEKER", glyph: 'H', duration: 15.0, stack: STACK_REFRESH, weapon: "seeker"},
+	PU_CHARGE: {name: "CHARGE", glyph: 'C', duration: 15.0, stack: STACK_REFRESH, weapon: "charger"},
+}
+
+const (
+	PU_SPREAD_ANGLE    = 0.2   //Angle in radians between the shots of a spread
+	PU_SPEED_MULT      = 1.5   //Multiplies the player's top speed
+	PU_MAGNET_RANGE    = 96.0  //Distance from which love is pulled in
+	PU_MAGNET_STRENGTH = 600.0 //Acceleration of pulled love in pixels per second squared
+	PICKUP_LIFE        = 8.0   //Time in seconds before dropped pickups vanish
+	PICKUP_BLINK_TIME  = 3.0   //Pickups blink for this long before vanishing
+)
+
+type ActivePowerUp struct {
+	timer float64 //Time left
+	level int
+}
+
+//The power-ups that the player has
+type PowerUps [PU_COUNT]ActivePowerUp
+
+//Gives the power-up according to its stacking rule. Returns true if it wasn't already active.
+func (pu *PowerUps) Grant(kind PowerUpType) bool {
+	def := &powerUpDefs[kind]
+	p := &pu[kind]
+	started := p.timer <= 0.0
+	//A new weapon replaces the old one
+	if def.weapon != "" {
+		for i := range pu {
+			if PowerUpType(i) != kind && powerUpDefs[i].weapon != "" {
+				pu[i] = ActivePowerUp{}
+			}
+		}
+	}
+	switch def.stack {
+	case STACK_REFRESH:
+		p.timer = def.duration
+	case STACK_EXTEND:
+		if started {
+			p.timer = 0.0
+		}
+		p.timer = min(p.timer+def.duration, def.limit)
+	case STACK_LEVEL:
+		if started {
+			p.level = 0
+		}
+		p.level = min(p.level+1, int(def.limit))
+		p.timer = def.duration
+	}
+	if started && p.level == 0 {
+		p.level = 1
+	}
+	return started
+}
+
+func (pu *PowerUps) Active(kind PowerUpType) bool {
+	return pu[kind].timer > 0.0
+}
+
+//Returns the level of the power-up, or zero if it isn't active
+func (pu *PowerUps) Level(kind PowerUpType) int {
+	if !pu.Active(kind) {
+		return 0
+	}
+	return pu[kind].level
+}
+
+//Returns the name of the weapon given by an active power-up, or an empty string if there isn't one
+func (pu *PowerUps) Weapon() string {
+	for i, def := range powerUpDefs {
+		if def.weapon != "" && pu.Active(PowerUpType(i)) {
+			return def.weapon
+		}
+	}
+	return ""
+}
+
+//Returns the changes that the active power-ups make to whatever weapon is being used
+func (pu *PowerUps) WeaponMods() WeaponMods {
+	mods := WeaponMods{extraShots: pu.Level(PU_SPREAD), rateScale: 1.0}
+	if pu.Active(PU_RAPID) {
+		mods.rateScale /= 2.0
+	}
+	return mods
+}
+
+//Uses up one level of the power-up, ending it if none are left
+func (pu *PowerUps) Spend(kind PowerUpType) {
+	pu[kind].level--
+	if pu[kind].level <= 0 {
+		pu[kind].timer = 0.0
+	}
+}
+
+//Counts down the timers. Calls the function for each power-up that ran out.
+func (pu *PowerUps) Update(deltaTime float64, expired func(kind PowerUpType)) {
+	for i := range pu {
+		if pu[i].timer > 0.0 {
+			pu[i].timer -= deltaTime
+			if pu[i].timer <= 0.0 {
+				pu[i].timer = 0.0
+				pu[i].level = 0
+				expired(PowerUpType(i))
+			}
+		}
+	}
+}
+
+//A power-up's chance to be dropped by a killed monster
+type PowerUpDrop struct {
+	kind   PowerUpType
+	chance float64
+}
+
+//A power-up lying on the ground
+type Pickup struct {
+	kind PowerUpType
+	life float64 //Time left before it vanishes. Zero means it stays forever.
+}
+
+var sprPickups [PU_COUNT]*Sprite
+
+func init() {
+	for i, def := range powerUpDefs {
+		sprPickups[i] = NewSprite(GlyphRect(def.glyph), vmath.NewVec(-4.0, -4.0), false, false, 0)
+	}
+}
+
+func AddPickup(game *Game, kind PowerUpType, x, y, life float64) *Object {
+	return game.AddObject(&Object{
+		pos: vmath.NewVec(x, y), radius: 6.0, colType: CT_PICKUP,
+		layer:      RL_GROUND,
+		sprites:    []*Sprite{sprPickups[kind]},
+		components: []Component{&Pickup{kind: kind, life: life}},
+	})
+}
+
+func (pk *Pickup) Update(game *Game, obj *Object) {
+	if pk.life <= 0.0 {
+		return
+	}
+	pk.life -= game.deltaTime
+	if pk.life <= 0.0 {
+		obj.removeMe = true
+		AddPoof(game, obj.pos.X, obj.pos.Y)
+	} else if pk.life < PICKUP_BLINK_TIME {
+		obj.hidden = int(pk.life*8.0)%2 == 0
+	}
+}
+
+func (pk *Pickup) OnCollision(game *Game, obj, other *Object) {
+	if other.HasColType(CT_PLAYER) && !obj.removeMe {
+		obj.removeMe = true
+		audio.PlaySound("love_get")
+		AddStarBurst(game, obj.pos.X, obj.pos.Y)
+		Emit_Signal(PowerUpCollected{Player: other, Kind: pk.kind, Pos: obj.pos.Clone()})
+	}
+}
+
+//Picks a power-up from the mission's drop table, or returns false if the roll comes up empty
+func RollPowerUp(mission *Mission) (PowerUpType, bool) {
+	roll := rand.Float64()
+	for _, d := range mission.powerUps {
+		if roll < d.chance {
+			return d.kind, true
+		}
+		roll -= d.chance
+	}
+	return 0, false
+}
+
+//Makes killed monsters drop power-ups according to the mission's drop table. The subscription is added to the scope.
+func WatchPowerUpDrops(game *Game, scope *SignalScope) {
+	scope.Track(Listen_Signal(func(ev EnemyKilled) {
+		if kind, ok := RollPowerUp(game.mission); ok {
+			AddPickup(game, kind, ev.Pos.X, ev.Pos.Y, PICKUP_LIFE)
+		}
+	}))
+}
+
+//Places the mission's pylon power-ups next to randomly chosen pylons. These ones don't vanish.
+func SpawnPylonPickups(game *Game) {
+	if game.mission.pylonPowerUps <= 0 || len(game.mission.powerUps) == 0 {
+		return
+	}
+	spots := make([]*Tile, 0, 64)
+	for j := range game.level.tiles {
+		for i := range game.level.tiles[j] {
+			if game.level.tiles[j][i].tt != TT_PYLON {
+				continue
+			}
+			//Use the first open space next to the pylon
+			for _, d := range [][2]int{{0, 1}, {1, 0}, {0, -1}, {-1, 0}} {
+				if t := game.level.GetTile(i+d[0], j+d[1], false); t != nil && !t.IsSolid() {
+					spots = append(spots, t)
+					break
+				}
+			}
+		}
+	}
+	rand.Shuffle(len(spots), func(i, j int) { spots[i], spots[j] = spots[j], spots[i] })
+	//Pylon pickups are always something, so the chances in the table are only used for weighting
+	total := 0.0
+	for _, d := range game.mission.powerUps {
+		total += d.chance
+	}
+	for n := 0; n < game.mission.pylonPowerUps && n < len(spots); n++ {
+		roll := rand.Float64() * total
+		for _, d := range game.mission.powerUps {
+			if roll < d.chance {
+				AddPickup(game, d.kind, spots[n].centerX, spots[n].centerY, 0.0)
+				break
+			}
+			roll -= d.chance
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"log"
+	"time"
+)
+
+const RECORDS_FILE = "records.json"
+
+//The fastest completion of a mission on one difficulty
+type BestTime struct {
+	Mission    int       `json:"mission"` //The number after the last mission is the fight with the demon
+	Difficulty string    `json:"difficulty"`
+	Time       float64   `json:"time"` //Seconds
+	Date       time.Time `json:"date"`
+}
+
+//Contents of the records save file
+type RecordSave struct {
+	Difficulty string     `json:"difficulty"` //Last difficulty chosen on the title screen
+	BestTimes  []BestTime `json:"bestTimes"`
+}
+
+var __recordSave *RecordSave
+
+//Returns the saved records, loading them if needed
+func recordSave() *RecordSave {
+	if __recordSave == nil {
+		__recordSave = &RecordSave{Difficulty: DIFF_NORMAL.String()}
+		if err := LoadSaveFile(RECORDS_FILE, __recordSave); err != nil {
+			log.Println("Cannot load records: ", err)
+		}
+	}
+	return __recordSave
+}
+
+func writeRecordSave() {
+	if err := WriteSaveFile(RECORDS_FILE, recordSave()); err != nil {
+		log.Println("Cannot save records: ", err)
+	}
+}
+
+//Sets the difficulty from the one that was last chosen
+func LoadDifficulty() {
+	if diff, ok := ParseDifficulty(recordSave().Difficulty); ok {
+		__difficulty = diff
+	}
+}
+
+//Changes the difficulty used for new games and remembers it
+func SetDifficulty(diff Difficulty) {
+	__difficulty = diff
+	recordSave().Difficulty = diff.String()
+	writeRecordSave()
+}
+
+//Returns the best time for the mission on the difficulty, or nil if it hasn't been completed yet
+func GetBestTime(mission int, diff Difficulty) *BestTime {
+	save := recordSave()
+	for i := range save.BestTimes {
+		if bt := &save.BestTimes[i]; bt.Mission == mission && bt.Difficulty == diff.String() {
+			return bt
+		}
+	}
+	return nil
+}
+
+//Saves the time if it beats the best time for the mission on the difficulty. Returns true if it did.
+func RecordBestTime(mission int, diff Difficulty, seconds float64) bool {
+	if __botPlaying {
+		return false
+	}
+	if bt := GetBestTime(mission, diff); bt != nil {
+		if seconds >= bt.Time {
+			return false
+		}
+		bt.Time, bt.Date = seconds, time.Now()
+	} else {
+		save := recordSave()
+		save.BestTimes = append(save.BestTimes, BestTime{Mission: mission, Difficulty: diff.String(), Time: seconds, Date: time.Now()})
+	}
+	writeRecordSave()
+	return true
+}
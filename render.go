@@ -0,0 +1,95 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+//Determines the order in which objects are drawn. Layers are drawn from lowest to highest.
+type RenderLayer int
+
+const (
+	RL_GROUND      RenderLayer = iota - 1 //Items and props lying on the floor
+	RL_ACTORS                             //Enemies and the cat. This is the default since it's the zero value.
+	RL_PROJECTILES                        //Shots
+	RL_PLAYER                             //The player stays on top of the other actors so it can't be lost in a crowd
+	RL_EFFECTS                            //Explosions, poofs, and other particles
+	RL_OVERLAY                            //Drawn above everything else in the world
+	RL_COUNT       = int(RL_OVERLAY-RL_GROUND) + 1
+)
+
+//Layers whose objects are sorted so that lower objects are drawn in front of higher ones
+var layerYSort = [RL_COUNT]bool{
+	int(RL_ACTORS - RL_GROUND): true,
+}
+
+//Collects the objects to be drawn each frame, separated into layers.
+type RenderQueue struct {
+	layers [RL_COUNT][]*Object
+}
+
+func NewRenderQueue() *RenderQueue {
+	rq := &RenderQueue{}
+	for i := range rq.layers {
+		rq.layers[i] = make([]*Object, 0, 64)
+	}
+	return rq
+}
+
+//Empties the queue while keeping its memory around for the next frame
+func (rq *RenderQueue) Clear() {
+	for i := range rq.layers {
+		rq.layers[i] = rq.layers[i][:0]
+	}
+}
+
+func (rq *RenderQueue) Push(obj *Object) {
+	i := int(obj.layer - RL_GROUND)
+	if i < 0 || i >= RL_COUNT {
+		i = int(RL_ACTORS - RL_GROUND)
+	}
+	rq.layers[i] = append(rq.layers[i], obj)
+}
+
+//Sorts the layers that need it. Objects at the same height keep the order in which they were pushed.
+func (rq *RenderQueue) Sort() {
+	for i, layer := range rq.layers {
+		if layerYSort[i] {
+			sort.SliceStable(layer, func(a, b int) bool {
+				return layer[a].pos.Y < layer[b].pos.Y
+			})
+		}
+	}
+}
+
+func (rq *RenderQueue) Draw(screen *ebiten.Image, camMat *ebiten.GeoM) {
+	for _, layer := range rq.layers {
+		for _, obj := range layer {
+			objM := &ebiten.DrawImageOptions{}
+			objM.GeoM.Concat(*camMat)
+			objM.GeoM.Translate(math.Floor(obj.pos.X), math.Floor(obj.pos.Y))
+			for _, spr := range obj.sprites {
+				spr.Draw(screen, &objM.GeoM)
+			}
+		}
+	}
+}
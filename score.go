@@ -0,0 +1,277 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/thetophatdemon/feta-feles-rebirth/audio"
+)
+
+const (
+	COMBO_WINDOW       = 2.0  //Time in seconds after a kill during which the next one adds to the combo
+	COMBO_STEP         = 2    //Number of kills in a combo for each step up of the multiplier
+	COMBO_MAX_MULT     = 8    //Highest multiplier
+	RUNE_POINTS        = 25   //Times the rune's place in its chain reaction
+	ASCEND_POINTS      = 500  //For each ascension
+	PAR_POINTS         = 1000 //For finishing under the par time
+	PAR_SECOND_POINTS  = 20   //For each second under the par time
+	DEMON_POINTS       = 5000
+	HIGH_SCORES_KEPT   = 10
+	HIGH_SCORES_SHOWN  = 5 //Number of high scores listed on the results screen
+	HIGH_SCORES_FORMAT = "scores-%d.json"
+)
+
+//What points were given for
+type ScoreKind int
+
+const (
+	SK_KILLS ScoreKind = iota
+	SK_RUNES           //Rune chain explosions
+	SK_ASCEND
+	SK_PAR
+	SK_STAGE //Clearing a stage of endless mode
+	SK_COUNT
+)
+
+var scoreKindNames = [SK_COUNT]string{"KILLS", "RUNE CHAINS", "ASCENSION", "PAR TIME", "STAGE CLEAR"}
+
+//Points scored during one mission, along with the state of the combo
+type Score struct {
+	points     [SK_COUNT]int
+	combo      int     //Kills in the current combo
+	comboTimer float64 //Time left to make the next kill before the combo ends
+	bestCombo  int
+}
+
+func (sc *Score) Total() int {
+	total := 0
+	for _, p := range sc.points {
+		total += p
+	}
+	return total
+}
+
+//Returns what kill and rune points are currently multiplied by
+func (sc *Score) Multiplier() int {
+	if sc.combo <= 0 {
+		return 1
+	}
+	return min(1+(sc.combo-1)/COMBO_STEP, COMBO_MAX_MULT)
+}
+
+//Counts a kill toward the combo and scores it
+func (sc *Score) AddKill(points int) {
+	sc.combo++
+	sc.comboTimer = COMBO_WINDOW
+	sc.bestCombo = max(sc.bestCombo, sc.combo)
+	sc.points[SK_KILLS] += points * sc.Multiplier()
+}
+
+func (sc *Score) BreakCombo() {
+	sc.combo = 0
+	sc.comboTimer = 0.0
+}
+
+//Ends the combo once too much time has passed since the last kill
+func (sc *Score) Update(deltaTime float64) {
+	if sc.comboTimer > 0.0 {
+		sc.comboTimer -= deltaTime
+		if sc.comboTimer <= 0.0 {
+			sc.BreakCombo()
+		}
+	}
+}
+
+//Returns the points for killing something with the given archetype name
+func KillPoints(archetype string) int {
+	if arch := archetypes[archetype]; arch != nil {
+		return arch.points
+	}
+	if archetype == "demon" {
+		return DEMON_POINTS
+	}
+	return 0
+}
+
+//Returns the bonus for finishing the mission after the given number of seconds, which is zero if it's over the par time
+func ParBonus(parTime int, seconds float64) int {
+	if seconds >= float64(parTime) {
+		return 0
+	}
+	return PAR_POINTS + PAR_SECOND_POINTS*int(math.Floor(float64(parTime)-seconds))
+}
+
+//Adds points to the game's score as things happen. The subscriptions are added to the scope.
+func WatchScore(game *Game, scope *SignalScope) {
+	sc := game.score
+	scope.Track(Listen_Signal(func(ev EnemyKilled) {
+		if points := KillPoints(ev.Archetype); points > 0 {
+			sc.AddKill(points)
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerHurt) {
+		sc.BreakCombo()
+	}))
+	scope.Track(Listen_Signal(func(ev RuneExploded) {
+		sc.points[SK_RUNES] += RUNE_POINTS * ev.Chain * sc.Multiplier()
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerAscended) {
+		sc.points[SK_ASCEND] += ASCEND_POINTS
+	}))
+	scope.Track(Listen_Signal(func(ev CatDied) {
+		sc.points[SK_PAR] += ParBonus(game.mission.parTime, game.elapsedTime)
+	}))
+	scope.Track(Listen_Signal(func(ev BossDefeated) {
+		sc.points[SK_PAR] += ParBonus(game.mission.parTime, game.elapsedTime)
+	}))
+}
+
+//An entry in a mission's high score table
+type HighScore struct {
+	Score      int       `json:"score"`
+	Difficulty string    `json:"difficulty"`
+	Time       float64   `json:"time"` //Seconds taken to finish the mission
+	Date       time.Time `json:"date"`
+}
+
+//Contents of a mission's high score file
+type HighScoreSave struct {
+	Scores []HighScore `json:"scores"` //Best first
+}
+
+//Returns the high scores for the mission. The number after the last mission is the fight with the demon.
+func LoadHighScores(mission int) *HighScoreSave {
+	save := &HighScoreSave{}
+	if err := LoadSaveFile(fmt.Sprintf(HIGH_SCORES_FORMAT, mission), save); err != nil {
+		log.Println("Cannot load high scores: ", err)
+	}
+	return save
+}
+
+//Adds the score to the mission's table if it's good enough. Returns its place in the table, or -1 if it didn't make it.
+func (save *HighScoreSave) Record(mission int, entry HighScore) int {
+	//Ties go to the older score
+	rank := sort.Search(len(save.Scores), func(i int) bool { return save.Scores[i].Score < entry.Score })
+	if rank >= HIGH_SCORES_KEPT || __botPlaying {
+		return -1
+	}
+	save.Scores = append(save.Scores, HighScore{})
+	copy(save.Scores[rank+1:], save.Scores[rank:])
+	save.Scores[rank] = entry
+	if len(save.Scores) > HIGH_SCORES_KEPT {
+		save.Scores = save.Scores[:HIGH_SCORES_KEPT]
+	}
+	if err := WriteSaveFile(fmt.Sprintf(HIGH_SCORES_FORMAT, mission), save); err != nil {
+		log.Println("Cannot save high scores: ", err)
+	}
+	return rank
+}
+
+//Shown after a mission is completed. Breaks down the score and lists the mission's high scores.
+type ResultsScreen struct {
+	SignalScope
+	game   *Game
+	next   func() AppState //Called to get what comes after the results
+	uiRoot *UINode
+}
+
+func NewResultsScreen(game *Game, next func() AppState) *ResultsScreen {
+	return &ResultsScreen{game: game, next: next}
+}
+
+func (rs *ResultsScreen) Enter() {
+	game := rs.game
+	sc := game.score
+
+	rs.uiRoot = EmptyUINode()
+	panel := CreateUIBox(image.Rect(136, 40, 160, 48), image.Rect(16, 8, SCR_WIDTH-16, SCR_HEIGHT-8), true)
+	rs.uiRoot.AddChild(&panel.UINode)
+
+	heading := fmt.Sprintf("MISSION %d", game.missionNumber)
+	if game.endless != nil {
+		heading = fmt.Sprintf("STAGE %d", game.endless.stage-1)
+	} else if game.boss != nil {
+		heading = "THE DEMON"
+	}
+	titleBox := CreateUIBox(image.Rect(112, 40, 136, 48), image.Rect(0, 0, 8*len(heading)+16, 16), true) //Header
+	titleBox.AddChild(&GenerateText(heading, image.Rect(8, 4, 2048, 2048)).UINode)
+	panel.AddChild(&titleBox.UINode)
+
+	lineRect := image.Rect(0, 0, SCR_WIDTH-32-16, 8)
+	lineLen := lineRect.Dx() / 8
+	addLine := func(label, value string) {
+		text := label + fmt.Sprintf("%*s", lineLen-len(label), value)
+		panel.AddChild(&GenerateText(text, lineRect).UINode)
+	}
+	for i, points := range sc.points {
+		if ScoreKind(i) == SK_STAGE && game.endless == nil {
+			continue
+		}
+		addLine(scoreKindNames[i], fmt.Sprint(points))
+	}
+	addLine("BEST COMBO", fmt.Sprint(sc.bestCombo))
+	addLine("TOTAL", fmt.Sprint(sc.Total()))
+
+	//Endless mode keeps its own table for whole runs
+	if game.endless == nil {
+		save := LoadHighScores(game.missionNumber)
+		rank := save.Record(game.missionNumber, HighScore{Score: sc.Total(), Difficulty: game.difficulty.String(), Time: game.elapsedTime, Date: time.Now()})
+		panel.AddChild(&GenerateText("", lineRect).UINode)
+		if rank == 0 {
+			panel.AddChild(&GenerateText("NEW HIGH SCORE!", lineRect).UINode)
+		} else {
+			panel.AddChild(&GenerateText("HIGH SCORES", lineRect).UINode)
+		}
+		for i, entry := range save.Scores[:min(len(save.Scores), HIGH_SCORES_SHOWN)] {
+			marker := ""
+			if i == rank {
+				marker = " <"
+			}
+			line := fmt.Sprintf("%2d %-8d%-8s%s", i+1, entry.Score, entry.Difficulty, marker)
+			panel.AddChild(&GenerateText(line, lineRect).UINode)
+		}
+	}
+
+	panel.AddChild(&GenerateText("", lineRect).UINode)
+	panel.AddChild(&GenerateText("CLICK OR SPACE TO CONTINUE", lineRect).UINode)
+
+	panel.ArrangeChildren(image.Rect(4, 4, 4, 4), true)
+}
+
+func (rs *ResultsScreen) Leave() {
+	rs.uiRoot.Unlink()
+}
+
+func (rs *ResultsScreen) Update(deltaTime float64) {
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		audio.PlaySound("menu")
+		ChangeAppState(rs.next())
+	}
+}
+
+func (rs *ResultsScreen) Draw(screen *ebiten.Image) {
+	rs.uiRoot.Draw(screen, nil)
+}
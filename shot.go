@@ -19,6 +19,7 @@ package main
 
 import (
 	"image"
+	"math"
 
 	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
 )
@@ -29,6 +30,15 @@ type Shot struct {
 	enemy   bool         //Will this shot hurt the player?
 	bounces int          //Number of times shot can hit the wall before dying
 	anim    *Anim
+	damage  int
+	pierce  int       //Number of targets the shot can pass through before it stops
+	hits    []*Object //Targets that the shot has gone into
+	damaged []*Object //Targets that the shot has already hurt
+
+	homing      float64 //Turning speed in radians per second toward the nearest target
+	homingRange float64
+	target      *Object
+	retarget    float64 //Time until the next search for a target
 }
 
 func AddShot(game *Game, pos, dir *vmath.Vec2f, speed float64, enemy bool) *Shot {
@@ -48,47 +58,64 @@ func init() {
 }
 
 func AddBouncyShot(game *Game, pos, dir *vmath.Vec2f, speed float64, enemy bool, bounces int) *Shot {
+	shot := NewShot(dir, speed, enemy, bounces)
+	var frames []*Sprite
+	switch {
+	case enemy && bounces > 0:
+		frames = sprShotEnemyBouncy
+	case enemy:
+		frames = []*Sprite{sprShotEnemy}
+	case bounces > 0:
+		frames = sprShotPlayerBouncy
+	default:
+		frames = []*Sprite{sprShotPlayer}
+	}
+	shot.Spawn(game, pos, frames, 0.5)
+	return shot
+}
+
+//Makes a shot without adding it to the game, so that its settings can be changed first
+func NewShot(dir *vmath.Vec2f, speed float64, enemy bool, bounces int) *Shot {
 	shot := &Shot{
 		vel:     dir.Clone().Normalize().Scale(speed),
-		life:    5.0,
+		life:    SHOT_LIFE,
 		enemy:   enemy,
 		bounces: bounces,
 		anim:    nil,
+		damage:  1,
 	}
-	var spr *Sprite
-	ct := CT_SHOT
+	if bounces > 0 { //Bouncy shots do double damage
+		shot.damage++
+	}
+	return shot
+}
+
+//Adds an object for the shot at the position. Shots with more than one frame are animated.
+func (shot *Shot) Spawn(game *Game, pos *vmath.Vec2f, frames []*Sprite, animSpeed float64) *Object {
 	//Set animation & Collision
-	if bounces > 0 {
-		shot.anim = &Anim{
-			loop:  true,
-			speed: 0.5,
-		}
+	ct := CT_SHOT
+	if shot.bounces > 0 {
 		ct |= CT_BOUNCYSHOT
 	}
-	if enemy {
+	if shot.enemy {
 		ct |= CT_ENEMYSHOT
-		if shot.anim != nil {
-			shot.anim.frames = sprShotEnemyBouncy
-			spr = shot.anim.frames[0]
-		} else {
-			spr = sprShotEnemy
-		}
 	} else {
 		ct |= CT_PLAYERSHOT
-		if shot.anim != nil {
-			shot.anim.frames = sprShotPlayerBouncy
-			spr = shot.anim.frames[0]
-		} else {
-			spr = sprShotPlayer
+	}
+	if len(frames) > 1 {
+		shot.anim = &Anim{
+			frames: frames,
+			loop:   true,
+			speed:  animSpeed,
 		}
 	}
 
-	game.objects.PushBack(&Object{
+	return game.AddObject(&Object{
 		pos: pos.Clone(), radius: 4.0, colType: ct,
-		sprites:    []*Sprite{spr},
+		layer:      RL_PROJECTILES,
+		sprites:    []*Sprite{frames[0]},
 		components: []Component{shot},
 	})
-	return shot
 }
 
 func (shot *Shot) Update(game *Game, obj *Object) {
@@ -96,12 +123,15 @@ func (shot *Shot) Update(game *Game, obj *Object) {
 		shot.anim.Update(game.deltaTime)
 		obj.sprites[0] = shot.anim.GetSprite()
 	}
+	if shot.homing > 0.0 {
+		shot.Home(game, obj)
+	}
 	//Wall bounce
 	hit, normal, hitTile := game.level.SphereIntersects(obj.pos.Clone().Add(shot.vel.Clone().Scale(game.deltaTime)), obj.radius)
 	if hit {
 		if hitTile != nil && hitTile.tt == TT_RUNE && obj.HasColType(CT_BOUNCYSHOT) {
 			hitTile.SetType(TT_EMPTY)
-			AddExplosion(game, hitTile.centerX, hitTile.centerY)
+			new(RuneChain).Detonate(game, hitTile)
 		}
 		if shot.bounces > 0 {
 			if normal.X != 0.0 || normal.Y != 0.0 {
@@ -122,8 +152,92 @@ func (shot *Shot) Update(game *Game, obj *Object) {
 	}
 }
 
+//Turns the shot toward the nearest target in range
+func (shot *Shot) Home(game *Game, obj *Object) {
+	if shot.target != nil && (shot.target.removeMe || containsObject(shot.hits, shot.target)) {
+		shot.target = nil
+	}
+	if shot.target == nil {
+		shot.retarget -= game.deltaTime
+		if shot.retarget > 0.0 {
+			return
+		}
+		shot.retarget = SHOT_RETARGET_TIME
+		if shot.target = shot.FindTarget(game, obj); shot.target == nil {
+			return
+		}
+	}
+	current := math.Atan2(shot.vel.Y, shot.vel.X)
+	wanted := math.Atan2(shot.target.pos.Y-obj.pos.Y, shot.target.pos.X-obj.pos.X)
+	turn := math.Remainder(wanted-current, math.Pi*2.0)
+	maxTurn := shot.homing * game.deltaTime
+	turn = math.Max(-maxTurn, math.Min(maxTurn, turn))
+	shot.vel = vmath.VecFromAngle(current+turn, shot.vel.Length())
+}
+
+//Returns the closest thing within homing range that the shot can hurt, or nil if there isn't one.
+//Only bouncy shots go after the cat, since the others can't hurt it.
+func (shot *Shot) FindTarget(game *Game, obj *Object) *Object {
+	mask := CT_ENEMY
+	if shot.enemy {
+		mask = CT_PLAYER
+	} else if obj.HasColType(CT_BOUNCYSHOT) {
+		mask |= CT_CAT
+	}
+	var best *Object
+	bestDist := shot.homingRange
+	for objE := game.objects.Front(); objE != nil; objE = objE.Next() {
+		other := objE.Value.(*Object)
+		if !other.HasColType(mask) || other.removeMe || containsObject(shot.hits, other) {
+			continue
+		}
+		if dist := other.pos.Clone().Sub(obj.pos).Length(); dist < bestDist {
+			best, bestDist = other, dist
+		}
+	}
+	return best
+}
+
+//Returns how much the shot hurts the target. Piercing shots can touch a target for several updates, but only hurt it once.
+func (shot *Shot) Damage(target *Object) int {
+	if containsObject(shot.damaged, target) {
+		return 0
+	}
+	shot.damaged = append(shot.damaged, target)
+	return shot.damage
+}
+
+//Returns how much the object hurts the target if it's a shot, or zero otherwise
+func ShotDamage(other, target *Object) int {
+	for _, c := range other.components {
+		if shot, ok := c.(*Shot); ok {
+			damage := shot.Damage(target)
+			if damage > 0 && len(shot.damaged) == 1 {
+				Emit_Signal(ShotLanded{Shot: other, Target: target})
+			}
+			return damage
+		}
+	}
+	return 0
+}
+
+func containsObject(objs []*Object, obj *Object) bool {
+	for _, o := range objs {
+		if o == obj {
+			return true
+		}
+	}
+	return false
+}
+
 func (shot *Shot) OnCollision(game *Game, obj, other *Object) {
 	if (other.HasColType(CT_ENEMY) && !shot.enemy) || (other.HasColType(CT_PLAYER) && shot.enemy) || other.HasColType(CT_CAT|CT_BARREL) {
-		obj.removeMe = true
+		//Piercing shots keep going until they've gone into enough different targets
+		if !containsObject(shot.hits, other) {
+			shot.hits = append(shot.hits, other)
+		}
+		if len(shot.hits) > shot.pierce {
+			obj.removeMe = true
+		}
 	}
 }
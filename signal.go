@@ -17,86 +17,316 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
 package main
 
-import "container/list"
+import (
+	"log"
+	"sort"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
 
 type Signal int
 
 const (
-	SIGNAL_PLAYER_MOVED  Signal = iota //Fires continuously as long as the player is moving. For the tutorial mission.
-	SIGNAL_PLAYER_SHOT                 //Fires every time the player shoots. For the tutorial mission.
-	SIGNAL_PLAYER_EDGE				   //Fires when the camera reaches the edge of the map. For the tutorial mission.
-	SIGNAL_PLAYER_ASCEND               //Fires to indicate when the player ascends
-	SIGNAL_CAT_RULE                    //Fires when the player tries to shoot the cat without being ascended
-	SIGNAL_CAT_DIE                     //Fires when the cat is killed
-	SIGNAL_GAME_START                  //Fires at the start of the game after the intro transition
-	SIGNAL_GAME_INIT                   //Fires before the game level is generated
-	SIGNAL_LOVE_CHANGE				   //Fires when the love meter is increased or decreased
+	SIGNAL_PLAYER_MOVED   Signal = iota //Fires continuously as long as the player is moving. For the tutorial mission.
+	SIGNAL_PLAYER_SHOT                  //Fires every time the player shoots. For the tutorial mission.
+	SIGNAL_PLAYER_EDGE                  //Fires when the camera reaches the edge of the map. For the tutorial mission.
+	SIGNAL_PLAYER_ASCEND                //Fires to indicate when the player ascends
+	SIGNAL_CAT_RULE                     //Fires when the player tries to shoot the cat without being ascended
+	SIGNAL_CAT_DIE                      //Fires when the cat is killed
+	SIGNAL_GAME_START                   //Fires at the start of the game after the intro transition
+	SIGNAL_GAME_INIT                    //Fires before the game level is generated
+	SIGNAL_LOVE_CHANGE                  //Fires when the love meter is increased or decreased
+	SIGNAL_ENEMY_HURT                   //Fires when an enemy takes damage
+	SIGNAL_ENEMY_KILLED                 //Fires when an enemy's health runs out
+	SIGNAL_PLAYER_HURT                  //Fires when the player takes damage
+	SIGNAL_PLAYER_WARP                  //Fires when the player warps across the edge of the map
+	SIGNAL_PLAYER_DESCEND               //Fires when the player loses their ascension
+	SIGNAL_RUNE_EXPLODE                 //Fires when a rune tile is set off
+	SIGNAL_ACHIEVEMENT                  //Fires when an achievement is unlocked
+	SIGNAL_NOISE                        //Fires when something loud enough for monsters to hear happens
+	SIGNAL_CAT_MEOW                     //Fires when the cat meows
+	SIGNAL_BOSS_DIE                     //Fires when the demon's death animation is over
+	SIGNAL_POWERUP                      //Fires when the player picks up a power-up
+	SIGNAL_STAGE_END                    //Fires when a stage of endless mode is cleared or its time runs out
+	SIGNAL_SHOT_LANDED                  //Fires when a projectile hurts something for the first time
 )
 
-//Represents the number of times a signal has been emitted
-var __signal_counts map[Signal]int
+//Data sent along with a signal. Each signal has its own event type.
+type Event interface {
+	Signal() Signal
+}
+
+type PlayerMoved struct {
+	Player *Object
+}
+
+type PlayerShot struct {
+	Player      *Object
+	Dir         *vmath.Vec2f //Direction the shot was fired in
+	Projectiles int          //Number of projectiles that came out
+}
+
+type PlayerEdge struct {
+	Player *Object
+}
+
+type PlayerAscended struct {
+	Player *Object
+}
+
+type CatRule struct {
+	Cat *Object
+}
+
+type CatDied struct {
+	Cat *Object
+	Pos *vmath.Vec2f
+}
+
+type GameStarted struct {
+	Game *Game
+}
+
+type GameInit struct {
+	Game    *Game
+	Mission int
+}
+
+type LoveChanged struct {
+	Game     *Game
+	Old, New int
+}
+
+type EnemyHurt struct {
+	Enemy     *Object
+	Archetype string
+	Source    *Object //What hurt the enemy
+	Damage    int
+}
+
+type EnemyKilled struct {
+	Enemy     *Object
+	Archetype string
+	Source    *Object //What dealt the final blow
+	Pos       *vmath.Vec2f
+}
+
+type PlayerHurt struct {
+	Player *Object
+	Source *Object //What hurt the player
+	Damage int     //Amount of love lost
+	Pos    *vmath.Vec2f
+}
+
+type PlayerWarped struct {
+	Player   *Object
+	From, To *vmath.Vec2f
+}
+
+type PlayerDescended struct {
+	Player *Object
+}
+
+type RuneExploded struct {
+	Pos   *vmath.Vec2f
+	Chain int //Number of runes set off so far by the chain reaction that this rune is part of
+}
+
+type AchievementUnlocked struct {
+	Achievement *Achievement
+}
+
+type NoiseMade struct {
+	Pos      *vmath.Vec2f
+	Loudness float64 //Distance in pixels from which the noise can be heard when there are no walls in the way
+	Source   *Object
+}
+
+type CatMeowed struct {
+	Cat *Object
+	Pos *vmath.Vec2f
+}
+
+type BossDefeated struct {
+	Boss *Object
+	Pos  *vmath.Vec2f
+}
+
+type PowerUpCollected struct {
+	Player *Object
+	Kind   PowerUpType
+	Pos    *vmath.Vec2f
+}
+
+type StageEnded struct {
+	Stage   int
+	Cleared bool //False if the time ran out
+	Pos     *vmath.Vec2f
+}
 
-//Returns the number of times the given signal has been emitted
-func Get_Signal_Count(sig Signal) int {
-	return __signal_counts[sig]
+//Piercing shots can hurt several targets, but only land once
+type ShotLanded struct {
+	Shot   *Object
+	Target *Object
 }
 
-type Observer interface {
-	HandleSignal(kind Signal, src interface{}, params map[string]interface{})
+func (PlayerMoved) Signal() Signal         { return SIGNAL_PLAYER_MOVED }
+func (PlayerShot) Signal() Signal          { return SIGNAL_PLAYER_SHOT }
+func (PlayerEdge) Signal() Signal          { return SIGNAL_PLAYER_EDGE }
+func (PlayerAscended) Signal() Signal      { return SIGNAL_PLAYER_ASCEND }
+func (CatRule) Signal() Signal             { return SIGNAL_CAT_RULE }
+func (CatDied) Signal() Signal             { return SIGNAL_CAT_DIE }
+func (GameStarted) Signal() Signal         { return SIGNAL_GAME_START }
+func (GameInit) Signal() Signal            { return SIGNAL_GAME_INIT }
+func (LoveChanged) Signal() Signal         { return SIGNAL_LOVE_CHANGE }
+func (EnemyHurt) Signal() Signal           { return SIGNAL_ENEMY_HURT }
+func (EnemyKilled) Signal() Signal         { return SIGNAL_ENEMY_KILLED }
+func (PlayerHurt) Signal() Signal          { return SIGNAL_PLAYER_HURT }
+func (PlayerWarped) Signal() Signal        { return SIGNAL_PLAYER_WARP }
+func (PlayerDescended) Signal() Signal     { return SIGNAL_PLAYER_DESCEND }
+func (RuneExploded) Signal() Signal        { return SIGNAL_RUNE_EXPLODE }
+func (AchievementUnlocked) Signal() Signal { return SIGNAL_ACHIEVEMENT }
+func (NoiseMade) Signal() Signal           { return SIGNAL_NOISE }
+func (CatMeowed) Signal() Signal           { return SIGNAL_CAT_MEOW }
+func (BossDefeated) Signal() Signal        { return SIGNAL_BOSS_DIE }
+func (PowerUpCollected) Signal() Signal    { return SIGNAL_POWERUP }
+func (StageEnded) Signal() Signal          { return SIGNAL_STAGE_END }
+func (ShotLanded) Signal() Signal          { return SIGNAL_SHOT_LANDED }
+
+//A handler registered with Listen_Signal. Cancelling it stops the handler from receiving any more events.
+type Subscription struct {
+	kind      Signal
+	handler   func(Event) //Stored without the event type so that all signals can share one map
+	cancelled bool
 }
 
-var __observers map[Signal]*list.List
+var __observers map[Signal][]*Subscription
 
-//Retrieve observer map and initialize if needed
-func observers() map[Signal]*list.List {
+//Registers the handler to be called whenever an event of its type is emitted
+func Listen_Signal[E Event](handler func(E)) *Subscription {
 	if __observers == nil {
-		__observers = make(map[Signal]*list.List)
+		__observers = make(map[Signal][]*Subscription)
+	}
+	var zero E
+	sub := &Subscription{
+		kind: zero.Signal(),
+		handler: func(ev Event) {
+			handler(ev.(E))
+		},
 	}
-	return __observers
+	__observers[sub.kind] = append(__observers[sub.kind], sub)
+	return sub
 }
 
-func Listen_Signal(kind Signal, obs Observer) {
-	//Initialize list if haven't already
-	lst, ok := observers()[kind]
-	if !ok {
-		lst = list.New()
-		observers()[kind] = lst
+//Unregisters the handler. Safe to call more than once, and from inside of a handler.
+func (sub *Subscription) Cancel() {
+	if sub == nil || sub.cancelled {
+		return
 	}
-	//Exit if observer is already registered
-	for itr := lst.Front(); itr != nil; itr = itr.Next() {
-		c_obs := itr.Value.(Observer)
-		if c_obs == obs {
-			println("Observer already added.")
-			return
+	sub.cancelled = true
+	//A new slice is made so that any emission in progress can keep iterating over the old one
+	old := __observers[sub.kind]
+	subs := make([]*Subscription, 0, len(old))
+	for _, s := range old {
+		if s != sub {
+			subs = append(subs, s)
 		}
 	}
-	//Add observer to list
-	lst.PushBack(obs)
+	__observers[sub.kind] = subs
 }
 
-func Emit_Signal(kind Signal, src interface{}, params map[string]interface{}) {
-	//Update signal count
-	if __signal_counts == nil {
-		__signal_counts = make(map[Signal]int)
-	}
-	_, ok := __signal_counts[kind]
-	if !ok {
-		__signal_counts[kind] = 1
-	} else {
-		__signal_counts[kind] += 1
+//Signals with higher priority are delivered first when the queue is flushed. Unlisted signals have a priority of 0.
+var signalPriority = map[Signal]int{
+	SIGNAL_ENEMY_HURT:     2,
+	SIGNAL_ENEMY_KILLED:   2,
+	SIGNAL_PLAYER_HURT:    2,
+	SIGNAL_LOVE_CHANGE:    1,
+	SIGNAL_PLAYER_DESCEND: 1,
+	SIGNAL_CAT_DIE:        -1, //Ends the mission, so everything else that happened should be handled first
+	SIGNAL_BOSS_DIE:       -1,
+}
+
+//Maximum number of events delivered in one flush, in case handlers keep emitting each other's signals forever
+const MAX_SIGNAL_CHAIN = 1024
+
+var (
+	__signalQueue  []Event
+	__queueSignals bool //Set while events are being collected instead of delivered
+	__dispatching  bool //Set while handlers are being called
+)
+
+//Sends the event to every handler listening for its type.
+//While the queue is active, or when called from inside of a handler, the event is delivered later instead.
+func Emit_Signal[E Event](ev E) {
+	__signalQueue = append(__signalQueue, ev)
+	if !__queueSignals {
+		Flush_Signal_Queue()
 	}
-	//Exit when no observers
-	lst, ok := observers()[kind]
-	if !ok {
+}
+
+//Starts collecting emitted events so that they can be delivered together at a safe point
+func Begin_Signal_Queue() {
+	__queueSignals = true
+}
+
+//Stops collecting events and delivers everything that was collected
+func End_Signal_Queue() {
+	__queueSignals = false
+	Flush_Signal_Queue()
+}
+
+//Delivers queued events in order of priority. Events emitted by the handlers are delivered afterwards in the same flush.
+func Flush_Signal_Queue() {
+	//Guard against re-entry. The flush further up the stack will deliver whatever was just queued.
+	if __dispatching {
 		return
 	}
-	//Make empty map if passed nil
-	if params == nil {
-		params = make(map[string]interface{})
+	__dispatching = true
+	defer func() { __dispatching = false }()
+
+	delivered := 0
+	for len(__signalQueue) > 0 {
+		batch := __signalQueue
+		__signalQueue = nil
+		sort.SliceStable(batch, func(i, j int) bool {
+			return signalPriority[batch[i].Signal()] > signalPriority[batch[j].Signal()]
+		})
+		for _, ev := range batch {
+			for _, sub := range __observers[ev.Signal()] {
+				if !sub.cancelled {
+					sub.handler(ev)
+				}
+			}
+			delivered++
+			if delivered > MAX_SIGNAL_CHAIN {
+				log.Println("Signal chain is too long. Dropping the rest of the queue.")
+				__signalQueue = nil
+				return
+			}
+		}
 	}
-	//Callback on all listening observers
-	for itr := lst.Front(); itr != nil; itr = itr.Next() {
-		obs := itr.Value.(Observer)
-		obs.HandleSignal(kind, src, params)
+}
+
+//Holds on to subscriptions so that they can all be cancelled at once when their owner goes away.
+//Every AppState has one, which is released when the app leaves that state.
+type SignalScope struct {
+	subs []*Subscription
+}
+
+//Adds the subscription to the scope and returns it
+func (scope *SignalScope) Track(sub *Subscription) *Subscription {
+	scope.subs = append(scope.subs, sub)
+	return sub
+}
+
+//Cancels every subscription in the scope
+func (scope *SignalScope) Release() {
+	for _, sub := range scope.subs {
+		sub.Cancel()
 	}
+	scope.subs = nil
+}
+
+func (scope *SignalScope) Signals() *SignalScope {
+	return scope
 }
@@ -0,0 +1,123 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+//Counts what the player has done over a span of play
+type Stats struct {
+	ShotsFired    int            `json:"shotsFired"` //Projectiles, so a spread shot counts once for each
+	Hits          int            `json:"hits"`       //Player projectiles that damaged something. Piercing ones count once.
+	Kills         map[string]int `json:"kills"`      //By archetype
+	TimesHurt     int            `json:"timesHurt"`
+	DamageTaken   int            `json:"damageTaken"` //Love the player was supposed to lose from getting hurt
+	Warps         int            `json:"warps"`
+	LoveCollected int            `json:"loveCollected"`
+	LoveLost      int            `json:"loveLost"`
+	Ascensions    int            `json:"ascensions"`
+	Descents      int            `json:"descents"`
+	MoveFrames    int            `json:"moveFrames"` //Number of updates during which the player was moving
+	EdgeFrames    int            `json:"edgeFrames"` //Number of updates during which the camera was at the edge of the map
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		Kills: make(map[string]int),
+	}
+}
+
+//Statistics for the current run through the campaign. They are reset when the first mission starts.
+var __runStats *Stats = NewStats()
+
+func (st *Stats) TotalKills() int {
+	total := 0
+	for _, n := range st.Kills {
+		total += n
+	}
+	return total
+}
+
+//Returns the fraction of shots that hit something
+func (st *Stats) Accuracy() float64 {
+	if st.ShotsFired == 0 {
+		return 0.0
+	}
+	return float64(st.Hits) / float64(st.ShotsFired)
+}
+
+//Subscribes to the signals that update the given stats. The subscriptions are added to the scope.
+//This should be called before other subscriptions are made so that handlers see the stats with the current event counted.
+func TrackStats(scope *SignalScope, stats ...*Stats) {
+	scope.Track(Listen_Signal(func(ev PlayerMoved) {
+		for _, st := range stats {
+			st.MoveFrames++
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerEdge) {
+		for _, st := range stats {
+			st.EdgeFrames++
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerShot) {
+		for _, st := range stats {
+			st.ShotsFired += ev.Projectiles
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev ShotLanded) {
+		if ev.Shot.HasColType(CT_PLAYERSHOT) {
+			for _, st := range stats {
+				st.Hits++
+			}
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev EnemyKilled) {
+		if ev.Archetype != "" {
+			for _, st := range stats {
+				st.Kills[ev.Archetype]++
+			}
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerHurt) {
+		for _, st := range stats {
+			st.TimesHurt++
+			st.DamageTaken += ev.Damage
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerWarped) {
+		for _, st := range stats {
+			st.Warps++
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev LoveChanged) {
+		for _, st := range stats {
+			if ev.New > ev.Old {
+				st.LoveCollected += ev.New - ev.Old
+			} else {
+				st.LoveLost += ev.Old - ev.New
+			}
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerAscended) {
+		for _, st := range stats {
+			st.Ascensions++
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerDescended) {
+		for _, st := range stats {
+			st.Descents++
+		}
+	}))
+}
@@ -0,0 +1,156 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"math"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+const (
+	FLOCK_CELL_SIZE      = 64.0 //Width of the cells that mobs are sorted into for neighbor searches
+	DEFAULT_FLOCK_RADIUS = 48.0
+)
+
+//Weights of the group behaviors that mobs of an archetype mix into their movement. Zero values turn a behavior off.
+type SteeringDef struct {
+	Radius       float64 `json:"radius"`       //Distance within which other mobs of the same archetype count as neighbors
+	Separation   float64 `json:"separation"`   //Moving away from neighbors that are too close
+	Alignment    float64 `json:"alignment"`    //Moving in the same direction as neighbors
+	Cohesion     float64 `json:"cohesion"`     //Moving toward the middle of the group
+	Surround     float64 `json:"surround"`     //Spreading out around the player to come at them from different sides
+	KeepDistance float64 `json:"keepDistance"` //Distance in pixels that the mob tries to stay away from the player while it can see them
+}
+
+func (sd *SteeringDef) Enabled() bool {
+	return sd.Separation != 0.0 || sd.Alignment != 0.0 || sd.Cohesion != 0.0 || sd.Surround != 0.0 || sd.KeepDistance > 0.0
+}
+
+type flockMember struct {
+	obj *Object
+	mob *Mob
+}
+
+type flockCell struct {
+	x, y int
+}
+
+//Sorts steering mobs into cells by position so that they can find their neighbors without checking every other mob.
+//Mobs add themselves as they update, and are found by searches during the next update.
+type Flock struct {
+	cells, nextCells map[flockCell][]flockMember
+}
+
+func NewFlock() *Flock {
+	return &Flock{
+		cells:     make(map[flockCell][]flockMember),
+		nextCells: make(map[flockCell][]flockMember),
+	}
+}
+
+//Makes the mobs added since the last call available to searches
+func (fl *Flock) Update() {
+	fl.cells, fl.nextCells = fl.nextCells, fl.cells
+	for k := range fl.nextCells {
+		delete(fl.nextCells, k)
+	}
+}
+
+func (fl *Flock) Add(obj *Object, mob *Mob) {
+	cell := flockCell{int(math.Floor(obj.pos.X / FLOCK_CELL_SIZE)), int(math.Floor(obj.pos.Y / FLOCK_CELL_SIZE))}
+	fl.nextCells[cell] = append(fl.nextCells[cell], flockMember{obj, mob})
+}
+
+//Calls the function for each living mob of the archetype within the radius of the position, besides the one given
+func (fl *Flock) Neighbors(obj *Object, radius float64, fn func(other *Object, mob *Mob, offset *vmath.Vec2f, dist float64)) {
+	minX, minY := int(math.Floor((obj.pos.X-radius)/FLOCK_CELL_SIZE)), int(math.Floor((obj.pos.Y-radius)/FLOCK_CELL_SIZE))
+	maxX, maxY := int(math.Floor((obj.pos.X+radius)/FLOCK_CELL_SIZE)), int(math.Floor((obj.pos.Y+radius)/FLOCK_CELL_SIZE))
+	for j := minY; j <= maxY; j++ {
+		for i := minX; i <= maxX; i++ {
+			for _, m := range fl.cells[flockCell{i, j}] {
+				if m.obj == obj || m.obj.removeMe || m.mob.dead || m.obj.archetype != obj.archetype {
+					continue
+				}
+				offset := m.obj.pos.Clone().Sub(obj.pos)
+				if dist := offset.Length(); dist < radius {
+					fn(m.obj, m.mob, offset, dist)
+				}
+			}
+		}
+	}
+}
+
+//Mixes the mob's group behaviors into the direction it wants to go in, and returns the direction to actually move in.
+//Mobs without steering just get the direction back.
+func (mb *Mob) Steer(game *Game, obj *Object, desired *vmath.Vec2f) *vmath.Vec2f {
+	sd := &mb.steering
+	if !sd.Enabled() {
+		return desired
+	}
+	dir := desired.Clone()
+	if dir.Length() > 0.0 {
+		dir.Normalize()
+	}
+
+	//Back off from the player if they're too close, but otherwise keep going the way the mob wanted
+	if sd.KeepDistance > 0.0 && mb.seesPlayer && mb.distToPlayer < sd.KeepDistance && mb.distToPlayer > 0.0 {
+		retreat := 1.0 - mb.distToPlayer/sd.KeepDistance
+		dir.Add(mb.vecToPlayer.Clone().Scale(-2.0 * retreat / mb.distToPlayer))
+	}
+
+	radius := sd.Radius
+	if radius <= 0.0 {
+		radius = DEFAULT_FLOCK_RADIUS
+	}
+	separation, alignment, center, surround := vmath.ZeroVec(), vmath.ZeroVec(), vmath.ZeroVec(), vmath.ZeroVec()
+	count := 0
+	toPlayer := mb.vecToPlayer.Clone().Scale(-1.0) //The mob's offset from the player
+	game.flock.Neighbors(obj, radius, func(other *Object, om *Mob, offset *vmath.Vec2f, dist float64) {
+		count++
+		if dist > 0.0 {
+			//Push harder the closer they are
+			separation.Sub(offset.Clone().Scale((radius - dist) / (radius * dist)))
+		}
+		alignment.Add(om.movement)
+		center.Add(offset)
+		//Go around the player in whichever way moves the mob further from the neighbor's side
+		if om.hunting {
+			theirs := other.pos.Clone().Sub(game.playerObj.pos)
+			side := 1.0
+			if vmath.VecCross(toPlayer, theirs) > 0.0 {
+				side = -1.0
+			}
+			surround.Add(vmath.NewVec(-toPlayer.Y, toPlayer.X).Scale(side))
+		}
+	})
+	if count == 0 {
+		return dir
+	}
+	dir.Add(separation.Scale(sd.Separation))
+	if alignment.Length() > 0.0 {
+		dir.Add(alignment.Normalize().Scale(sd.Alignment))
+	}
+	if center.Length() > 0.0 {
+		dir.Add(center.Scale(1.0 / float64(count)).Normalize().Scale(sd.Cohesion))
+	}
+	if mb.hunting && surround.Length() > 0.0 {
+		dir.Add(surround.Normalize().Scale(sd.Surround))
+	}
+	return dir
+}
@@ -0,0 +1,196 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+//One line of a telemetry log. The cmd/telemetry-stats tool reads these, so keep the two in sync.
+type TelemetryEntry struct {
+	Time        time.Time   `json:"time"`
+	MissionTime float64     `json:"missionTime"` //Seconds since the mission started
+	Mission     int         `json:"mission"`
+	Stage       int         `json:"stage,omitempty"` //Stage of endless mode, whose missions all have the same number
+	Difficulty  string      `json:"difficulty"`
+	Seed        int64       `json:"seed"` //Seed the mission's level was generated with
+	Event       string      `json:"event"`
+	Pos         *[2]float64 `json:"pos,omitempty"`
+	Archetype   string      `json:"archetype,omitempty"` //Enemy involved in the event, or what hurt the player
+	Value       int         `json:"value,omitempty"`
+	Delta       int         `json:"delta,omitempty"`
+}
+
+//Names of the events written to the log
+const (
+	TE_MISSION_START = "mission_start" //Value is the par time in seconds
+	TE_ASCEND        = "ascend"
+	TE_DESCEND       = "descend"
+	TE_CAT_RULE      = "cat_rule"
+	TE_LOVE_CHANGE   = "love_change" //Value is the new amount of love, Delta is the change
+	TE_KILL          = "kill"
+	TE_PLAYER_HURT   = "player_hurt" //Value is the love lost
+	TE_WARP          = "warp"
+	TE_COMPLETE      = "complete"     //Value is 1 if the par time was beaten
+	TE_STAGE_FAILED  = "stage_failed" //The time for a stage of endless mode ran out
+)
+
+//Writes gameplay events to a JSON Lines file, one file per run through the campaign
+type TelemetryRecorder struct {
+	file    *os.File
+	encoder *json.Encoder
+	game    *Game
+	stage   int //Stage of endless mode that the game is, or zero
+}
+
+//Directory that telemetry logs are written to. Telemetry is disabled when this is empty.
+var __telemetryDir string
+var __telemetry *TelemetryRecorder
+
+//Starts a new log file for the run. Does nothing if telemetry is disabled.
+func StartTelemetryRun() {
+	if __telemetryDir == "" {
+		return
+	}
+	if __telemetry != nil {
+		__telemetry.file.Close()
+		__telemetry = nil
+	}
+	if err := os.MkdirAll(__telemetryDir, 0755); err != nil {
+		log.Println("Cannot create telemetry directory: ", err)
+		return
+	}
+	//Runs can start in the same second, such as when bot trials run side by side, so never overwrite another run's log
+	base := fmt.Sprintf("run-%s-%d", time.Now().Format("20060102-150405"), os.Getpid())
+	path := filepath.Join(__telemetryDir, base+".jsonl")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	for n := 2; os.IsExist(err); n++ {
+		path = filepath.Join(__telemetryDir, fmt.Sprintf("%s-%d.jsonl", base, n))
+		file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	}
+	if err != nil {
+		log.Println("Cannot create telemetry log: ", err)
+		return
+	}
+	__telemetry = &TelemetryRecorder{
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}
+}
+
+//Records the game's events until the scope is released
+func (tr *TelemetryRecorder) RecordMission(game *Game, scope *SignalScope) {
+	tr.game = game
+	tr.stage = 0
+	if game.endless != nil {
+		tr.stage = game.endless.stage
+	}
+	tr.Write(TelemetryEntry{Event: TE_MISSION_START, Value: game.mission.parTime})
+	scope.Track(Listen_Signal(func(ev PlayerAscended) {
+		tr.Write(TelemetryEntry{Event: TE_ASCEND, Pos: telemetryPos(ev.Player.pos)})
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerDescended) {
+		tr.Write(TelemetryEntry{Event: TE_DESCEND, Pos: telemetryPos(ev.Player.pos)})
+	}))
+	scope.Track(Listen_Signal(func(ev CatRule) {
+		tr.Write(TelemetryEntry{Event: TE_CAT_RULE, Pos: telemetryPos(ev.Cat.pos)})
+	}))
+	scope.Track(Listen_Signal(func(ev LoveChanged) {
+		if ev.New != ev.Old {
+			tr.Write(TelemetryEntry{Event: TE_LOVE_CHANGE, Value: ev.New, Delta: ev.New - ev.Old})
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev EnemyKilled) {
+		tr.Write(TelemetryEntry{Event: TE_KILL, Archetype: ev.Archetype, Pos: telemetryPos(ev.Pos)})
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerHurt) {
+		tr.Write(TelemetryEntry{Event: TE_PLAYER_HURT, Archetype: describeHazard(ev.Source), Value: ev.Damage, Pos: telemetryPos(ev.Pos)})
+	}))
+	scope.Track(Listen_Signal(func(ev PlayerWarped) {
+		tr.Write(TelemetryEntry{Event: TE_WARP, Pos: telemetryPos(ev.From)})
+	}))
+	complete := func(pos *vmath.Vec2f) {
+		beatPar := 0
+		if game.elapsedTime < float64(game.mission.parTime) {
+			beatPar = 1
+		}
+		tr.Write(TelemetryEntry{Event: TE_COMPLETE, Value: beatPar, Pos: telemetryPos(pos)})
+	}
+	scope.Track(Listen_Signal(func(ev CatDied) {
+		//Endless mode's stages are written when they end, since the time can run out first
+		if game.endless == nil {
+			complete(ev.Pos)
+		}
+	}))
+	scope.Track(Listen_Signal(func(ev BossDefeated) {
+		complete(ev.Pos)
+	}))
+	scope.Track(Listen_Signal(func(ev StageEnded) {
+		if ev.Cleared {
+			complete(ev.Pos)
+		} else {
+			tr.Write(TelemetryEntry{Event: TE_STAGE_FAILED, Pos: telemetryPos(ev.Pos)})
+		}
+	}))
+}
+
+//Fills in the common fields of the entry and writes it out
+func (tr *TelemetryRecorder) Write(entry TelemetryEntry) {
+	entry.Time = time.Now()
+	entry.MissionTime = tr.game.elapsedTime
+	entry.Mission = tr.game.missionNumber
+	entry.Stage = tr.stage
+	entry.Difficulty = tr.game.difficulty.String()
+	entry.Seed = tr.game.seed
+	if err := tr.encoder.Encode(&entry); err != nil {
+		log.Println("Cannot write telemetry: ", err)
+	}
+}
+
+func telemetryPos(pos *vmath.Vec2f) *[2]float64 {
+	if pos == nil {
+		return nil
+	}
+	return &[2]float64{pos.X, pos.Y}
+}
+
+//Names the thing that hurt the player for the log
+func describeHazard(obj *Object) string {
+	switch {
+	case obj == nil:
+		return ""
+	case obj.archetype != "":
+		return obj.archetype
+	case obj.parent != nil && obj.parent.archetype != "":
+		return obj.parent.archetype
+	case obj.HasColType(CT_EXPLOSION):
+		return "explosion"
+	case obj.HasColType(CT_BOUNCYSHOT):
+		return "bouncy_shot"
+	case obj.HasColType(CT_ENEMYSHOT):
+		return "shot"
+	}
+	return "enemy"
+}
@@ -24,20 +24,28 @@ import (
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/thetophatdemon/feta-feles-rebirth/audio"
 	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
 )
 
 type TitleScreen struct {
+	SignalScope
 	title           *Object
 	logo            *Object
 	feles           *Object
 	uiRoot          *UINode
 	link            *UIText
 	enterText       *UIText
+	achieveButt     *UIBox
+	diffButt        *UIBox //Changes the difficulty
+	diffText        *UIText
+	endlessButt     *UIBox //Starts endless mode
+	gallery         *UIBox //Lists the achievements
 	flinchTimer     float64
 	blinkTimer      float64
 	missionSelect   bool
 	goodEnd, badEnd bool //Flags for when you return to the title screen after beating the game
+	bossEnd         bool //Set after the demon is defeated
 }
 
 func (ts *TitleScreen) Enter() {
@@ -54,10 +62,25 @@ func (ts *TitleScreen) Enter() {
 	ts.uiRoot.AddChild(&ts.link.UINode)
 	ts.enterText = GenerateText("CLICK OR SPACE TO BEGIN", image.Rect(SCR_WIDTH_H-10*8-12, SCR_HEIGHT_H+40.0, SCR_WIDTH_H+10*8+12, SCR_HEIGHT_H+56.0))
 	ts.uiRoot.AddChild(&ts.enterText.UINode)
+	ts.achieveButt = CreateUIBox(image.Rect(88, 40, 112, 48), image.Rect(SCR_WIDTH-108, SCR_HEIGHT-20, SCR_WIDTH-4, SCR_HEIGHT-4), true)
+	ts.achieveButt.AddChild(&GenerateText("ACHIEVEMENTS", image.Rect(4, 4, 2048, 2048)).UINode)
+	ts.uiRoot.AddChild(&ts.achieveButt.UINode)
+	ts.diffButt = CreateUIBox(image.Rect(88, 40, 112, 48), image.Rect(4, SCR_HEIGHT-20, 68, SCR_HEIGHT-4), true)
+	ts.diffText = GenerateText(__difficulty.String(), image.Rect(4, 4, 2048, 2048))
+	ts.diffButt.AddChild(&ts.diffText.UINode)
+	ts.uiRoot.AddChild(&ts.diffButt.UINode)
+	ts.endlessButt = CreateUIBox(image.Rect(88, 40, 112, 48), image.Rect(4, 4, 72, 20), true)
+	ts.endlessButt.AddChild(&GenerateText("ENDLESS", image.Rect(4, 4, 2048, 2048)).UINode)
+	ts.uiRoot.AddChild(&ts.endlessButt.UINode)
+	ts.gallery = GenerateAchievementGallery()
+	ts.gallery.visible = false
+	ts.uiRoot.AddChild(&ts.gallery.UINode)
 	if ts.goodEnd {
 		ts.feles = MakeFeles(FACE_SMILE, BODY_ANGEL, vmath.NewVec(SCR_WIDTH_H, SCR_HEIGHT_H-32.0))
 	} else if ts.badEnd {
 		ts.feles = MakeFeles(FACE_EMPTY, BODY_CAT, vmath.NewVec(SCR_WIDTH_H, SCR_HEIGHT_H-32.0))
+	} else if ts.bossEnd {
+		ts.feles = MakeFeles(FACE_SMILE, BODY_CAT, vmath.NewVec(SCR_WIDTH_H, SCR_HEIGHT_H-32.0))
 	} else {
 		ts.feles = MakeFeles(FACE_WINK, BODY_CAT, vmath.NewVec(SCR_WIDTH_H, SCR_HEIGHT_H-32.0))
 	}
@@ -68,7 +91,12 @@ func (ts *TitleScreen) Leave() {
 }
 
 func (ts *TitleScreen) Update(deltaTime float64) {
-	if !ts.missionSelect {
+	if ts.gallery.visible {
+		if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			ts.gallery.visible = false
+			audio.PlaySound("menu")
+		}
+	} else if !ts.missionSelect {
 		//Mission select cheat
 		cheatText += strings.ToLower(string(ebiten.InputChars()))
 		if strings.Contains(cheatText, "tdyeehaw") {
@@ -85,7 +113,7 @@ func (ts *TitleScreen) Update(deltaTime float64) {
 			for i := range missions {
 				missions[i].goodEndFlag = true
 			}
-			ChangeAppState(NewCutsceneState(7))
+			ChangeAppState(NewCutsceneState(len(missions) + SCENE_DEMON))
 		}
 		//Bad ending cheat
 		if strings.Contains(cheatText, "tdblyat") {
@@ -93,7 +121,7 @@ func (ts *TitleScreen) Update(deltaTime float64) {
 			for i := range missions {
 				missions[i].goodEndFlag = false
 			}
-			ChangeAppState(NewCutsceneState(7))
+			ChangeAppState(NewCutsceneState(len(missions) + SCENE_BOSS_LOSE))
 		}
 
 		ts.flinchTimer += deltaTime
@@ -114,7 +142,19 @@ func (ts *TitleScreen) Update(deltaTime float64) {
 			}
 		}
 
-		if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if ts.achieveButt.Clicked() {
+			ts.gallery.visible = true
+			audio.PlaySound("menu")
+		} else if ts.diffButt.Clicked() || inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+			SetDifficulty(__difficulty.Next())
+			ts.diffText.text = __difficulty.String()
+			ts.diffText.fillPos = len(ts.diffText.text)
+			ts.diffText.Regen()
+			audio.PlaySound("menu")
+		} else if ts.endlessButt.Clicked() {
+			audio.PlaySound("menu")
+			ChangeAppState(NewEndlessGame(NewEndlessRun()))
+		} else if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 			ChangeAppState(NewCutsceneState(0))
 		}
 	} else {
@@ -144,6 +184,33 @@ func (ts *TitleScreen) Draw(screen *ebiten.Image) {
 	ts.uiRoot.Draw(screen, nil)
 }
 
+//Makes a panel showing which achievements have been unlocked
+func GenerateAchievementGallery() *UIBox {
+	gallery := CreateUIBox(image.Rect(136, 40, 160, 48), image.Rect(16, 8, SCR_WIDTH-16, SCR_HEIGHT-8), true)
+
+	titleBox := CreateUIBox(image.Rect(112, 40, 136, 48), image.Rect(0, 0, 112, 16), true) //Header
+	titleBox.AddChild(&GenerateText("ACHIEVEMENTS", image.Rect(8, 4, 2048, 2048)).UINode)
+	gallery.AddChild(&titleBox.UINode)
+
+	lineRect := image.Rect(0, 0, SCR_WIDTH-32-16, 16)
+	lineLen := lineRect.Dx() / 8
+	for _, ach := range achievements {
+		status := "LOCKED"
+		if ach.Unlocked() {
+			status = "UNLOCKED"
+		}
+		//Name and status on the first line, description on the second
+		header := ach.name + strings.Repeat(" ", max(1, lineLen-len(ach.name)-len(status))) + status
+		gallery.AddChild(&GenerateText(header+ach.description, lineRect).UINode)
+	}
+
+	backText := GenerateText("CLICK OR SPACE TO RETURN", image.Rect(0, 0, 24*8, 8))
+	gallery.AddChild(&backText.UINode)
+
+	gallery.ArrangeChildren(image.Rect(4, 4, 4, 8), true)
+	return gallery
+}
+
 func (ts *TitleScreen) GenerateTitle() *Object {
 	var titleLetters []image.Rectangle
 	if ts.goodEnd {
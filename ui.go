@@ -231,13 +231,18 @@ func (text *UIText) Regen() {
 		if r > ' ' && r <= 'Z' {
 			charDestX := (i % lineLen) * 8
 			charDestY := (i / lineLen) * 8
-			charSrcX := (int(r-' ')%12)*8 + 64
-			charSrcY := (int(r-' ') / 12) * 8
-			text.sprites[i] = NewSprite(image.Rect(charSrcX, charSrcY, charSrcX+8, charSrcY+8), vmath.NewVec(float64(charDestX), float64(charDestY)), false, false, 0)
+			text.sprites[i] = NewSprite(GlyphRect(r), vmath.NewVec(float64(charDestX), float64(charDestY)), false, false, 0)
 		}
 	}
 }
 
+//Returns the area of the graphics page holding the font's character
+func GlyphRect(r rune) image.Rectangle {
+	charSrcX := (int(r-' ')%12)*8 + 64
+	charSrcY := (int(r-' ') / 12) * 8
+	return image.Rect(charSrcX, charSrcY, charSrcX+8, charSrcY+8)
+}
+
 func GenerateText(text string, dest image.Rectangle) *UIText {
 	text = strings.ToUpper(text)
 	uiText := &UIText{
@@ -0,0 +1,233 @@
+/*
+Copyright (C) 2021 Alexander Lunsford
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+
+	"github.com/thetophatdemon/feta-feles-rebirth/assets"
+	"github.com/thetophatdemon/feta-feles-rebirth/audio"
+	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
+)
+
+const (
+	PL_WEAPON          = "pea" //The weapon the player has when nothing else is switching it
+	SHOT_LIFE          = 5.0   //Default time in seconds before a shot disappears
+	SHOT_RETARGET_TIME = 0.25  //Time in seconds between homing shots' searches for a new target
+)
+
+//Describes a weapon as it is laid out in assets/weapons.json
+type weaponData struct {
+	Name         string             `json:"name"`
+	Rate         float64            `json:"rate"`  //Time in seconds between shots
+	Speed        float64            `json:"speed"` //Speed of projectiles
+	Bounces      int                `json:"bounces"`
+	Shots        int                `json:"shots"`       //Number of projectiles per shot, fanned out around the aimed direction
+	SpreadAngle  float64            `json:"spreadAngle"` //Angle in radians between the projectiles of a shot
+	Damage       int                `json:"damage"`
+	Life         float64            `json:"life"`
+	Pierce       int                `json:"pierce"`       //Number of enemies a projectile can pass through before it stops
+	Homing       float64            `json:"homing"`       //Turning speed in radians per second toward the nearest target
+	HomingRange  float64            `json:"homingRange"`  //Distance within which homing projectiles look for targets
+	Charge       float64            `json:"charge"`       //Time in seconds to reach full charge. Weapons that charge fire when the trigger is let go.
+	ChargeDamage int                `json:"chargeDamage"` //Extra damage at full charge. Partial charges get part of it.
+	ChargePierce int                `json:"chargePierce"` //Extra pierce at full charge
+	Sprites      map[string][][]int `json:"sprites"`      //"normal", and optionally "charged" for fully charged shots
+	AnimSpeed    float64            `json:"animSpeed"`
+	Sound        string             `json:"sound"`
+	Ascended     string             `json:"ascended"` //Weapon that this one turns into while the player is ascended
+}
+
+type Weapon struct {
+	name                       string
+	rate, speed                float64
+	bounces, shots             int
+	spreadAngle                float64
+	damage                     int
+	life                       float64
+	pierce                     int
+	homing, homingRange        float64
+	charge                     float64
+	chargeDamage, chargePierce int
+	sprites                    map[string][]*Sprite
+	animSpeed                  float64
+	sound                      string
+	ascended                   string
+}
+
+var weapons map[string]*Weapon
+
+func init() {
+	LoadWeapons(assets.ReadCompressedString(assets.JSON_WEAPONS))
+}
+
+//Parses weapon definitions and adds them to the registry. Definitions with existing names replace the old ones.
+func LoadWeapons(input io.Reader) {
+	var data []weaponData
+	if err := json.NewDecoder(input).Decode(&data); err != nil {
+		log.Fatalln("Cannot parse weapon definitions: ", err)
+	}
+	if weapons == nil {
+		weapons = make(map[string]*Weapon)
+	}
+	for _, d := range data {
+		wpn := &Weapon{
+			name:         d.Name,
+			rate:         d.Rate,
+			speed:        d.Speed,
+			bounces:      d.Bounces,
+			shots:        max(d.Shots, 1),
+			spreadAngle:  d.SpreadAngle,
+			damage:       d.Damage,
+			life:         d.Life,
+			pierce:       d.Pierce,
+			homing:       d.Homing,
+			homingRange:  d.HomingRange,
+			charge:       d.Charge,
+			chargeDamage: d.ChargeDamage,
+			chargePierce: d.ChargePierce,
+			sprites:      make(map[string][]*Sprite),
+			animSpeed:    d.AnimSpeed,
+			sound:        d.Sound,
+			ascended:     d.Ascended,
+		}
+		if wpn.spreadAngle == 0.0 {
+			wpn.spreadAngle = PU_SPREAD_ANGLE
+		}
+		if wpn.life == 0.0 {
+			wpn.life = SHOT_LIFE
+		}
+		if wpn.sound == "" {
+			wpn.sound = "player_shot"
+		}
+		for key, frames := range d.Sprites {
+			if wpn.sprites[key] = ParseSpriteFrames(frames); wpn.sprites[key] == nil {
+				log.Fatalf("Weapon %s has a malformed %s frame.\n", d.Name, key)
+			}
+		}
+		if len(wpn.sprites["normal"]) == 0 {
+			log.Fatalf("Weapon %s has no sprites.\n", d.Name)
+		}
+		weapons[d.Name] = wpn
+	}
+	//Check the references once everything is loaded, since weapons can refer to ones defined after them
+	for _, wpn := range weapons {
+		if _, ok := weapons[wpn.ascended]; wpn.ascended != "" && !ok {
+			log.Fatalf("Weapon %s turns into unknown weapon %s.\n", wpn.name, wpn.ascended)
+		}
+	}
+	for _, def := range powerUpDefs {
+		if _, ok := weapons[def.weapon]; def.weapon != "" && !ok {
+			log.Fatalf("Power-up %s gives unknown weapon %s.\n", def.name, def.weapon)
+		}
+	}
+}
+
+func GetWeapon(name string) *Weapon {
+	wpn, ok := weapons[name]
+	if !ok {
+		log.Println("No weapon named ", name)
+		return nil
+	}
+	return wpn
+}
+
+//Adjustments that power-ups make to whatever weapon is being used
+type WeaponMods struct {
+	extraShots int     //Projectiles added to each side of the spread
+	rateScale  float64 //Multiplies the time between shots
+}
+
+//Adds the weapon's projectiles at the position. Charge is the fraction of the full charge, for weapons that charge.
+func (wpn *Weapon) Fire(game *Game, pos, dir *vmath.Vec2f, mods WeaponMods, charge float64) {
+	frames := wpn.sprites["normal"]
+	damage, pierce := wpn.damage, wpn.pierce
+	if wpn.charge > 0.0 {
+		damage += int(math.Round(float64(wpn.chargeDamage) * charge))
+		if charge >= 1.0 {
+			pierce += wpn.chargePierce
+			if charged := wpn.sprites["charged"]; len(charged) > 0 {
+				frames = charged
+			}
+		}
+	}
+	//Projectiles fan out evenly on both sides of the aimed direction
+	count := wpn.Projectiles(mods)
+	angle := math.Atan2(dir.Y, dir.X)
+	for i := 0; i < count; i++ {
+		a := angle + (float64(i)-float64(count-1)/2.0)*wpn.spreadAngle
+		shot := NewShot(vmath.VecFromAngle(a, 1.0), wpn.speed, false, wpn.bounces)
+		shot.damage = damage
+		shot.life = wpn.life
+		shot.pierce = pierce
+		shot.homing = wpn.homing
+		shot.homingRange = wpn.homingRange
+		shot.Spawn(game, pos, frames, wpn.animSpeed)
+	}
+	audio.PlaySound(wpn.sound)
+}
+
+//Returns the number of projectiles that come out of each shot
+func (wpn *Weapon) Projectiles(mods WeaponMods) int {
+	return wpn.shots + 2*mods.extraShots
+}
+
+//Keeps track of when a weapon can fire next, and how much it's been charged
+type Trigger struct {
+	cooldown float64      //Time left until the weapon can fire again
+	charge   float64      //Time spent charging
+	aim      *vmath.Vec2f //Direction of the last shot or charge
+}
+
+//Fires the weapon in the given direction when it's ready. A nil direction means that the trigger isn't being held.
+//Weapons that charge build up while the trigger is held and fire when it's let go. Returns true if the weapon fired.
+func (tr *Trigger) Update(game *Game, obj *Object, wpn *Weapon, mods WeaponMods, dir *vmath.Vec2f) bool {
+	if tr.cooldown > 0.0 {
+		tr.cooldown -= game.deltaTime
+		return false
+	}
+	var charge float64
+	if wpn.charge > 0.0 {
+		if dir != nil {
+			tr.aim = dir.Clone()
+			tr.charge = min(tr.charge+game.deltaTime, wpn.charge)
+			return false
+		}
+		if tr.charge <= 0.0 {
+			return false
+		}
+		charge = tr.charge / wpn.charge
+	} else if dir != nil {
+		tr.aim = dir.Clone()
+	} else {
+		tr.charge = 0.0 //Let go of any charge built up by a weapon that was switched away from
+		return false
+	}
+	tr.charge = 0.0
+	wpn.Fire(game, obj.pos, tr.aim, mods, charge)
+	tr.cooldown = wpn.rate * mods.rateScale
+	return true
+}
+
+//Returns true while the weapon is cooling down or being charged
+func (tr *Trigger) Busy() bool {
+	return tr.cooldown > 0.0 || tr.charge > 0.0
+}
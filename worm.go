@@ -18,7 +18,6 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
-	"image"
 	"math"
 	"math/rand"
 
@@ -26,140 +25,162 @@ import (
 	"github.com/thetophatdemon/feta-feles-rebirth/vmath"
 )
 
-var sprWormHead *Sprite //First is left facing, second is right facing
-var sprWormHeadHurt *Sprite
-var sprWormHeadDie []*Sprite
-var sprWormHeadCharge *Sprite
-var sprWormBody []*Sprite
-var sprWormBodyDie []*Sprite
-var sprWormTail *Sprite
-
-func init() {
-	sprWormHead = NewSprite(image.Rect(0, 80, 16, 96), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprWormHeadHurt = NewSprite(image.Rect(16, 80, 32, 96), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprWormHeadDie = []*Sprite{
-		sprWormHead, sprWormHeadHurt, NewSprite(image.Rect(32, 80, 48, 96), vmath.NewVec(-8.0, -8.0), false, false, 0),
-	}
-	sprWormHeadCharge = NewSprite(image.Rect(80, 80, 96, 96), vmath.NewVec(-8.0, -8.0), false, false, 0)
-	sprWormBody = []*Sprite{
-		NewSprite(image.Rect(48, 80, 64, 96), vmath.NewVec(-8.0, -8.0), false, false, 0),
-		NewSprite(image.Rect(48, 80, 64, 96), vmath.NewVec(-8.0, -8.0), false, false, 1),
-		NewSprite(image.Rect(48, 80, 64, 96), vmath.NewVec(-8.0, -8.0), false, false, 2),
-	}
-	sprWormBodyDie = []*Sprite{sprWormBody[0], NewSprite(image.Rect(48, 48, 64, 64), vmath.NewVec(-8.0, -8.0), false, false, 0)}
-	sprWormTail = NewSprite(image.Rect(64, 80, 80, 96), vmath.NewVec(-8.0, -8.0), false, false, 0)
-}
-
-var wormCtr *ObjCtr
-
-func init() {
-	wormCtr = NewObjCtr()
-}
-
-const WORM_NSEGS = 6    //Number of body segments
-const WORM_QDIST = 12.0 //Distance between queue updates
-//Range of values for the turn timer to be set to
-const WORM_TURNTIME_MIN = 2.0
-const WORM_TURNTIME_MAX = 6.0
-const WORM_TURNTIME_RANGE = WORM_TURNTIME_MAX - WORM_TURNTIME_MIN
-
 type Worm struct {
 	Mob
-	segs                 [WORM_NSEGS]*Object      //Body segments, including tail
-	segTargets           [WORM_NSEGS]*vmath.Vec2f //Queue of previous head positions that the segments move towards
-	enqDistCtr           float64                  //Measures distance traveled since last enqueue, up to WORM_QDIST
-	segDeathTimer        float64                  //Timer for destroying segments in the death animation
-	turnSpeed, turnTimer float64
-	charging             bool
+	arch                     *Archetype
+	segs                     []*Object      //Body segments, including tail
+	segTargets               []*vmath.Vec2f //Queue of previous head positions that the segments move towards
+	enqDistCtr               float64        //Measures distance traveled since last enqueue, up to the segment spacing
+	segDeathTimer            float64        //Timer for destroying segments in the death animation
+	turnSpeed                float64
+	turnTimeMin, turnTimeMax float64 //Range of values for the turn timer to be set to
+	aimTimer                 float64 //Time spent facing the player while charging
 }
 
-func AddWorm(game *Game, x, y float64) (obj *Object, worm *Worm) {
-	worm = &Worm{
+func AddWorm(game *Game, arch *Archetype, x, y float64) *Object {
+	nSegs := int(arch.Param("segments", 6))
+	worm := &Worm{
 		Mob: Mob{
-			Actor:             NewActor(100.0, 100_000.0, 50_000.0),
-			health:            11,
+			Actor:             NewActor(arch.maxSpeed, arch.acceleration, arch.friction),
+			vision:            arch.vision,
+			health:            arch.health,
 			currAnim:          nil,
 			lastSeenPlayerPos: vmath.ZeroVec(),
 			vecToPlayer:       vmath.ZeroVec(),
 		},
-		turnSpeed: math.Pi,
-		turnTimer: rand.Float64()*WORM_TURNTIME_RANGE + WORM_TURNTIME_MIN,
+		arch:        arch,
+		segs:        make([]*Object, nSegs),
+		segTargets:  make([]*vmath.Vec2f, nSegs),
+		turnSpeed:   arch.Param("turnSpeed", math.Pi),
+		turnTimeMin: arch.Param("turnTimeMin", 2.0),
+		turnTimeMax: arch.Param("turnTimeMax", 6.0),
 	}
 	dir := vmath.RandomDirection()
 	worm.Move(dir.X, dir.Y)
-	for i := WORM_NSEGS - 1; i >= 0; i-- { //Working backwards to ensure correct sprite order
-		spr := sprWormBody[rand.Intn(len(sprWormBody))] //Select random body segment sprite
-		if i == WORM_NSEGS-1 {
-			spr = sprWormTail
+	//Worm code is attached to the head object
+	obj := &Object{
+		pos: vmath.NewVec(x, y), radius: arch.radius, colType: CT_ENEMY,
+		archetype:  arch.name,
+		sprites:    []*Sprite{arch.Sprite("normal")},
+		components: []Component{worm},
+	}
+	worm.ai = worm.NewAI(game, obj)
+	game.AddObject(obj)
+	//Body segments are children of the head, so they are hidden and removed along with it
+	bodySprites := arch.sprites["body"]
+	for i := range worm.segs {
+		spr := bodySprites[rand.Intn(len(bodySprites))] //Select random body segment sprite
+		if i == nSegs-1 {
+			spr = arch.Sprite("tail")
 		}
 		effect := &Effect{ //Effect component is added so segments can be animated
 			anim: Anim{frames: []*Sprite{spr}},
 		}
-		worm.segs[i] = &Object{
-			pos: vmath.NewVec(x, y), radius: 6.0, colType: CT_ENEMY,
+		worm.segs[i] = game.AddObject(obj.AttachChild(&Object{
+			pos: vmath.NewVec(x, y), radius: arch.Param("segmentRadius", 6.0), colType: CT_ENEMY,
 			sprites:    []*Sprite{spr},
 			components: []Component{effect},
-		}
-		game.AddObject(worm.segs[i])
+		}, 0.0, 0.0))
 	}
-	//Worm code is attached to the head object
-	obj = &Object{
-		pos: vmath.NewVec(x, y), radius: 7.0, colType: CT_ENEMY,
-		sprites:    []*Sprite{sprWormHead},
-		components: []Component{worm},
+	return obj
+}
+
+func (worm *Worm) RandomTurnTime() float64 {
+	return rand.Float64()*(worm.turnTimeMax-worm.turnTimeMin) + worm.turnTimeMin
+}
+
+//Worms slither around aimlessly, and occasionally turn to charge at the player if they can see them
+func (worm *Worm) NewAI(game *Game, obj *Object) *StateMachine {
+	sm := NewStateMachine(AI_WANDER)
+	sm.Define(AI_WANDER, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			sm.SetTimeout(worm.RandomTurnTime())
+		},
+		Update: func(game *Game, obj *Object) {
+			if sm.Expired() && worm.seesPlayer {
+				sm.Change(game, obj, AI_CHASE)
+				return
+			} else if worm.heardPos != nil {
+				sm.Change(game, obj, AI_SEARCH)
+				return
+			}
+			worm.Wander(game, obj, 64.0, worm.turnSpeed)
+		},
+		Exit: func(game *Game, obj *Object) {
+			//Reverse the direction of turning to ensure it doesn't get stuck in circles
+			worm.turnSpeed = -worm.turnSpeed
+		},
+		Next: AI_WANDER,
+	})
+	sm.Define(AI_CHASE, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			worm.aimTimer = 0.0
+			audio.PlaySoundAttenuated("roar", 256.0, obj.pos, game.camMin, game.camMax)
+		},
+		Update: func(game *Game, obj *Object) {
+			//Turn to charge at player
+			if worm.TurnToward(worm.vecToPlayer, game.deltaTime) {
+				worm.aimTimer += game.deltaTime
+				if worm.aimTimer > worm.turnTimeMax {
+					sm.Change(game, obj, AI_WANDER)
+				}
+			}
+		},
+	})
+	//Heads off toward a noise, and goes back to wandering once it's pointed the right way
+	sm.Define(AI_SEARCH, StateDef{
+		Update: func(game *Game, obj *Object) {
+			if worm.heardPos == nil || worm.TurnToward(worm.heardPos.Clone().Sub(obj.pos), game.deltaTime) {
+				sm.Change(game, obj, AI_WANDER)
+			}
+		},
+		Exit: func(game *Game, obj *Object) {
+			worm.heardPos = nil
+		},
+		Timeout: NOISE_SEARCH_TIME,
+		Next:    AI_WANDER,
+	})
+	sm.Define(AI_DYING, StateDef{
+		Enter: func(game *Game, obj *Object) {
+			worm.dead = true
+			worm.Move(0.0, 0.0)
+		},
+		Update: worm.UpdateDeath,
+	})
+	return sm
+}
+
+//Turns the worm's movement toward the direction. Returns true once it's close enough to facing that way.
+func (worm *Worm) TurnToward(dir *vmath.Vec2f, deltaTime float64) bool {
+	nDiff := dir.Clone().Normalize()
+	dp := vmath.VecDot(nDiff, worm.movement)
+	if dp >= 0.9 {
+		return true
 	}
-	game.AddObject(obj)
-	wormCtr.Inc()
-	return
+	cp := vmath.VecCross(nDiff, worm.movement) //Sign of cross product determines which way to turn
+	if cp != 0.0 {
+		cp /= math.Abs(cp) //1.0 if positive, -1.0 if negative
+	}
+	worm.Turn(math.Abs(worm.turnSpeed)*(-cp), deltaTime)
+	return false
 }
 
 func (worm *Worm) Update(game *Game, obj *Object) {
 	//Update sprites
 	if worm.hurtTimer > 0.0 || worm.dead {
-		obj.sprites[0] = sprWormHeadHurt
-	} else if worm.charging && int(game.elapsedTime*4.0)%2 == 0 {
-		obj.sprites[0] = sprWormHeadCharge
+		obj.sprites[0] = worm.arch.Sprite("hurt")
+	} else if worm.ai.State() == AI_CHASE && int(game.elapsedTime*4.0)%2 == 0 {
+		obj.sprites[0] = worm.arch.Sprite("attack")
 	} else {
-		obj.sprites[0] = sprWormHead
+		obj.sprites[0] = worm.arch.Sprite("normal")
 	}
 
 	if !worm.dead {
-		if !worm.charging {
-			//Occasionally reverse the direction of turning to ensure it doesn't get stuck in circles
-			worm.turnTimer -= game.deltaTime
-			if worm.turnTimer < 0.0 {
-				worm.turnTimer = rand.Float64()*WORM_TURNTIME_RANGE + WORM_TURNTIME_MIN
-				worm.turnSpeed = -worm.turnSpeed
-				if worm.seesPlayer {
-					worm.charging = true
-					worm.turnTimer = WORM_TURNTIME_MAX
-					audio.PlaySoundAttenuated("roar", 256.0, obj.pos, game.camMin, game.camMax)
-				}
-			}
-			worm.Wander(game, obj, 64.0, worm.turnSpeed)
-		} else {
-			//Turn to charge at player
-			nDiff := worm.vecToPlayer.Clone().Normalize()
-			dp := vmath.VecDot(nDiff, worm.movement)
-			if dp < 0.9 {
-				cp := vmath.VecCross(nDiff, worm.movement) //Sign of cross product determines which way to turn
-				if cp != 0.0 {
-					cp /= math.Abs(cp) //1.0 if positive, -1.0 if negative
-				}
-				worm.Turn(math.Abs(worm.turnSpeed)*(-cp), game.deltaTime)
-			} else {
-				worm.turnTimer -= game.deltaTime
-				if worm.turnTimer < 0.0 {
-					worm.charging = false
-					worm.turnTimer = rand.Float64()*WORM_TURNTIME_RANGE + WORM_TURNTIME_MIN
-				}
-			}
-		}
 		//Update the queue of body segment target positions
-		if worm.enqDistCtr > WORM_QDIST {
+		if worm.enqDistCtr > worm.arch.Param("segmentSpacing", 12.0) {
 			worm.enqDistCtr = 0.0
 			//Add to front of position queue and shift the rest backward
-			for i := WORM_NSEGS - 1; i > 0; i-- {
+			for i := len(worm.segTargets) - 1; i > 0; i-- {
 				worm.segTargets[i] = worm.segTargets[i-1]
 			}
 			worm.segTargets[0] = obj.pos.Clone()
@@ -177,50 +198,6 @@ func (worm *Worm) Update(game *Game, obj *Object) {
 				}
 			}
 		}
-	} else { //Death sequence
-		worm.Move(0.0, 0.0)
-		//Destroy segments one at a time
-		worm.segDeathTimer += game.deltaTime
-		if worm.segDeathTimer > 0.25 {
-			worm.segDeathTimer = 0.0
-			var i int
-			for i = WORM_NSEGS - 1; i >= 0; i-- {
-				//Find furthest segment not yet being destroyed
-				if worm.segs[i] != nil && !worm.segs[i].removeMe {
-					break
-				}
-			}
-			audio.PlaySound("enemy_die")
-			//Destroy head when segments are gone
-			if i < 0 {
-				worm.currAnim = &Anim{
-					frames: sprWormHeadDie,
-					speed:  0.1,
-					callback: func(a *Anim) {
-						if a.finished {
-							obj.removeMe = true
-							wormCtr.Dec()
-							AddLove(game, 3, obj.pos.X, obj.pos.Y)
-						}
-					},
-				}
-				worm.segDeathTimer = -1000.0
-			} else {
-				segObj := worm.segs[i]
-				fx := segObj.components[0].(*Effect)
-				fx.anim = Anim{
-					frames: sprWormBodyDie,
-					speed:  0.1,
-					callback: func(a *Anim) {
-						if a.finished {
-							segObj.removeMe = true
-							AddLove(game, 2, segObj.pos.X, segObj.pos.Y)
-						}
-					},
-				}
-				worm.segs[i] = nil
-			}
-		}
 	}
 
 	displace := obj.pos.Clone()
@@ -230,23 +207,76 @@ func (worm *Worm) Update(game *Game, obj *Object) {
 
 	displace.Sub(obj.pos)
 	worm.enqDistCtr += displace.Length()
+
+	//Keep the segments where they were moved to, now that the head has moved
+	for _, seg := range worm.segs {
+		if seg != nil {
+			seg.localPos = seg.pos.Clone().Sub(obj.pos)
+		}
+	}
 }
 
 func (worm *Worm) OnCollision(game *Game, obj, other *Object) {
 	//Do not collide with body segments
-	for _, seg := range worm.segs {
-		if seg == other {
-			goto skip
+	if other.parent != obj {
+		worm.Mob.OnCollision(game, obj, other)
+		if other.colType == CT_ENEMY {
+			worm.Turn(worm.turnSpeed, game.deltaTime)
+			//Put off the next change of course
+			switch worm.ai.State() {
+			case AI_WANDER:
+				worm.ai.SetTimeout(worm.ai.Time() + worm.turnTimeMax)
+			case AI_CHASE:
+				worm.aimTimer = 0.0
+			}
 		}
 	}
-	worm.Mob.OnCollision(game, obj, other)
-	if other.colType == CT_ENEMY {
-		worm.Turn(worm.turnSpeed, game.deltaTime)
-		worm.turnTimer = WORM_TURNTIME_MAX
-	}
-skip:
 	//Death
 	if worm.health <= 0 && !worm.dead {
-		worm.dead = true
+		worm.ai.Change(game, obj, AI_DYING)
+	}
+}
+
+//Destroys the segments one at a time, and then the head
+func (worm *Worm) UpdateDeath(game *Game, obj *Object) {
+	worm.segDeathTimer += game.deltaTime
+	if worm.segDeathTimer > 0.25 {
+		worm.segDeathTimer = 0.0
+		var i int
+		for i = len(worm.segs) - 1; i >= 0; i-- {
+			//Find furthest segment not yet being destroyed
+			if worm.segs[i] != nil && !worm.segs[i].removeMe {
+				break
+			}
+		}
+		audio.PlaySound("enemy_die")
+		//Destroy head when segments are gone
+		if i < 0 {
+			worm.currAnim = &Anim{
+				frames: worm.arch.sprites["die"],
+				speed:  worm.arch.dieSpeed,
+				callback: func(a *Anim) {
+					if a.finished {
+						obj.removeMe = true
+						worm.arch.DropLoot(game, obj.pos.X, obj.pos.Y)
+					}
+				},
+			}
+			worm.segDeathTimer = -1000.0
+		} else {
+			segObj := worm.segs[i]
+			fx := segObj.components[0].(*Effect)
+			fx.anim = Anim{
+				frames: worm.arch.sprites["bodyDie"],
+				speed:  worm.arch.dieSpeed,
+				callback: func(a *Anim) {
+					if a.finished {
+						segObj.removeMe = true
+						AddLove(game, int(worm.arch.Param("segmentLove", 2)), segObj.pos.X, segObj.pos.Y)
+					}
+				},
+			}
+			worm.segs[i] = nil
+		}
 	}
 }